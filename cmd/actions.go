@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chege/azfind/internal/actions"
+	"github.com/chege/azfind/internal/browser"
+	"github.com/chege/azfind/internal/cache"
+	"github.com/chege/azfind/internal/picker"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// actionsCmd groups commands that inspect the action registry.
+var actionsCmd = &cobra.Command{
+	Use:   "actions",
+	Short: "Inspect actions available in the picker",
+}
+
+// actionsListCmd lists all actions, or those applicable to a cached resource.
+var actionsListCmd = &cobra.Command{
+	Use:   "list [resource]",
+	Short: "List actions, optionally only those for a cached resource",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx, stop := signalContext()
+		defer stop()
+
+		reg, err := actionRegistry()
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			tbl := table.New("Action", "Key", "Types", "Description").WithWriter(os.Stdout)
+			for _, a := range reg.List() {
+				types := "all"
+				if len(a.Types) > 0 {
+					types = strings.Join(a.Types, ", ")
+				}
+				tbl.AddRow(a.Name, a.Key, types, a.Description)
+			}
+			tbl.Print()
+			return nil
+		}
+
+		db, err := cache.Open(ctx)
+		if err != nil {
+			return fmt.Errorf("open cache: %w", err)
+		}
+		defer func() {
+			if cerr := db.Close(); cerr != nil && err == nil {
+				err = fmt.Errorf("failed to close cache db: %w", cerr)
+			}
+		}()
+
+		rs, err := db.FindResourcesByExactName(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("find resources by exact name: %w", err)
+		}
+		if len(rs) == 0 {
+			return fmt.Errorf("no cached resource named %q; run `azf sync` to refresh", args[0])
+		}
+
+		for i, r := range picker.RankByType(rs, viper.GetStringSlice("picker.prefer_types")) {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("Actions for %s (%s in %s):\n\n", r.Name, r.Type, r.ResourceGroup)
+			tbl := table.New("Action", "Key", "Description").WithWriter(os.Stdout)
+			for _, a := range reg.For(r) {
+				tbl.AddRow(a.Name, a.Key, a.Description)
+			}
+			tbl.Print()
+		}
+		return nil
+	},
+}
+
+// bladeDefault is an entry of "portal.default_blades". It is a list rather
+// than a map because resource types contain dots, which viper treats as key
+// separators.
+type bladeDefault struct {
+	Type  string `mapstructure:"type"`
+	Blade string `mapstructure:"blade"`
+}
+
+// actionRegistry returns the built-in actions extended with those defined
+// under "actions:" in the config file.
+func actionRegistry() (*actions.Registry, error) {
+	var defaults []bladeDefault
+	if err := viper.UnmarshalKey("portal.default_blades", &defaults); err != nil {
+		return nil, fmt.Errorf("invalid portal.default_blades config: %w", err)
+	}
+	opts := actions.Options{DefaultBlades: map[string]string{}}
+	for _, d := range defaults {
+		opts.DefaultBlades[strings.ToLower(d.Type)] = d.Blade
+	}
+
+	var browserCfg browser.Config
+	if err := viper.UnmarshalKey("browser", &browserCfg); err != nil {
+		return nil, fmt.Errorf("invalid browser config: %w", err)
+	}
+	if noBrowser {
+		browserCfg.NoBrowser = true
+	}
+	opts.Browser = browser.New(browserCfg)
+
+	reg := actions.Default(opts)
+
+	var configs []actions.Config
+	if err := viper.UnmarshalKey("actions", &configs); err != nil {
+		return nil, fmt.Errorf("invalid actions config: %w", err)
+	}
+	if err := reg.RegisterConfig(configs, picker.Reserved()); err != nil {
+		return nil, fmt.Errorf("invalid actions config: %w", err)
+	}
+	return reg, nil
+}
+
+func init() {
+	actionsCmd.AddCommand(actionsListCmd)
+	rootCmd.AddCommand(actionsCmd)
+}
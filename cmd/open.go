@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"github.com/chege/azfind/internal/portal"
+	"github.com/spf13/cobra"
+)
+
+var openBlade string
+
+// openCmd opens a resource by name, optionally on a specific portal blade.
+var openCmd = &cobra.Command{
+	Use:   "open <name|id|url> [--blade <blade>]",
+	Short: "Open a cached resource in the Azure Portal",
+	Long: `Open a cached resource in the Azure Portal. An exact name match opens
+directly; otherwise the picker is shown with the matching resources. A full
+resource ID such as /subscriptions/<id>/resourceGroups/<rg>/providers/... opens
+directly too, even if the resource is not cached yet, and so does a portal
+link pasted from chat, e.g. https://portal.azure.com/#@<tenant>/resource/...;
+the link's blade is kept unless --blade or --copy says otherwise.
+
+--blade lands on a specific portal page instead of the overview. Known blades
+are overview, iam, activity, logs, metrics, configuration, networking,
+properties, locks and diagnostics; any other value is used as a raw portal
+path segment.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signalContext()
+		defer stop()
+
+		opts, err := searchOptions(openBlade)
+		if err != nil {
+			return err
+		}
+		return runSearch(ctx, cmd, args, opts)
+	},
+	ValidArgsFunction: completeResourceNames,
+}
+
+func init() {
+	openCmd.Flags().StringVar(&openBlade, "blade", "", "Portal blade to open, e.g. iam, logs, metrics")
+	openCmd.Flags().StringVar(&copyField, "copy", "", "Copy the resource ID (--copy) or portal URL (--copy=url) instead of opening it")
+	openCmd.Flags().Lookup("copy").NoOptDefVal = "id"
+
+	_ = openCmd.RegisterFlagCompletionFunc("blade", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		names := make([]string, 0, len(portal.Blades))
+		for _, b := range portal.Blades {
+			names = append(names, b.Name+"\t"+b.Title)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	rootCmd.AddCommand(openCmd)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/chege/azfind/internal/cache"
+	"github.com/chege/azfind/internal/preview"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var previewLive bool
+var previewNoColor bool
+
+// previewCmd renders the picker's preview pane for a resource ID. fzf runs it
+// for the highlighted line, so it prints problems instead of failing.
+var previewCmd = &cobra.Command{
+	Use:     "__preview <id>",
+	Aliases: []string{"preview"},
+	Short:   "Show the details of a cached resource",
+	Hidden:  true,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx, stop := signalContext()
+		defer stop()
+
+		db, err := cache.Open(ctx)
+		if err != nil {
+			return fmt.Errorf("open cache: %w", err)
+		}
+		defer func() {
+			if cerr := db.Close(); cerr != nil && err == nil {
+				err = fmt.Errorf("failed to close cache db: %w", cerr)
+			}
+		}()
+
+		live := viper.GetBool("preview.live")
+		if cmd.Flags().Changed("live") {
+			live = previewLive
+		}
+		d, err := preview.Load(ctx, db, args[0], preview.Options{
+			Related:       viper.GetInt("preview.related"),
+			Children:      viper.GetInt("preview.children"),
+			History:       viper.GetInt("preview.history"),
+			Live:          live,
+			Timeout:       viper.GetDuration("preview.timeout"),
+			ClientOptions: retryConfig().ClientOptions(),
+		})
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			fmt.Printf("%s is not cached. Run `azf sync` to refresh.\n", args[0])
+			return nil
+		}
+
+		preview.Render(os.Stdout, d, !previewNoColor && os.Getenv("NO_COLOR") == "")
+		return nil
+	},
+}
+
+func init() {
+	previewCmd.Flags().BoolVar(&previewLive, "live", false, "Fetch current details from Azure (default from preview.live)")
+	previewCmd.Flags().BoolVar(&previewNoColor, "no-color", false, "Disable colours")
+
+	viper.SetDefault("preview.live", false)
+	viper.SetDefault("preview.timeout", "3s")
+	viper.SetDefault("preview.related", 10)
+	viper.SetDefault("preview.children", 10)
+	viper.SetDefault("preview.history", 3)
+
+	rootCmd.AddCommand(previewCmd)
+}
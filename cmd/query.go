@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/chege/azfind/internal/azure"
+	"github.com/chege/azfind/internal/fzfui"
+	"github.com/chege/azfind/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queryFile          string
+	queryOutput        string
+	queryPick          bool
+	querySubscriptions []string
+	queryLimit         int
+)
+
+// queryCmd runs a KQL query against Resource Graph, bypassing the cache.
+var queryCmd = &cobra.Command{
+	Use:   "query [kql]",
+	Short: "Run a live Resource Graph query",
+	Long: `Run a KQL query against Azure Resource Graph, bypassing the cache, e.g.
+
+  azf query "Resources | where type =~ 'microsoft.web/sites' | project name, location, id"
+
+The query is read from --file instead of the argument if given; --file - reads
+it from stdin. Results are printed as a table, JSON or CSV (--output). With
+--pick, rows with an id column are shown in the picker to run actions on.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signalContext()
+		defer stop()
+
+		kql, err := queryText(args)
+		if err != nil {
+			return err
+		}
+		if err := output.Validate(queryOutput); err != nil {
+			return err
+		}
+
+		return runQuery(ctx, cmd, liveQuery{
+			KQL:           kql,
+			Subscriptions: querySubscriptions,
+			Limit:         queryLimit,
+			Output:        queryOutput,
+			Pick:          queryPick,
+		})
+	},
+}
+
+// defaultQueryLimit caps the rows of a live query unless --limit says otherwise.
+const defaultQueryLimit = 1000
+
+// liveQuery is a Resource Graph query and what to do with its result.
+type liveQuery struct {
+	KQL string
+	// Subscriptions to query; empty means all accessible ones.
+	Subscriptions []string
+	// Limit caps the rows; zero means no limit.
+	Limit int
+	// Output is the format rows are printed in unless Pick is set.
+	Output string
+	// Pick shows the rows in the picker; they need an id column.
+	Pick bool
+}
+
+// runQuery runs q against Resource Graph and prints or picks the rows.
+func runQuery(ctx context.Context, cmd *cobra.Command, q liveQuery) error {
+	cred, err := azure.GetCredential()
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	table, err := azure.Query(ctx, cred, q.KQL, q.Subscriptions, q.Limit, retryConfig().ClientOptions())
+	if err != nil {
+		return err
+	}
+	if q.Limit > 0 && len(table.Rows) == q.Limit {
+		_, _ = fmt.Fprintf(os.Stderr, "Showing the first %d rows; raise --limit for more.\n", q.Limit)
+	}
+
+	if !q.Pick {
+		return output.Write(os.Stdout, q.Output, table.Columns, table.Rows)
+	}
+
+	ids, err := idColumn(table)
+	if err != nil {
+		return err
+	}
+	opts, err := searchOptions("")
+	if err != nil {
+		return err
+	}
+	return pickerError(cmd, fzfui.RunPick(ctx, ids, fmt.Sprintf("%d query results", len(ids)), opts))
+}
+
+// queryText returns the query from --file or the argument.
+func queryText(args []string) (string, error) {
+	var kql string
+	switch {
+	case queryFile == "-":
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("read query from stdin: %w", err)
+		}
+		kql = string(b)
+	case queryFile != "":
+		b, err := os.ReadFile(queryFile)
+		if err != nil {
+			return "", fmt.Errorf("read query: %w", err)
+		}
+		kql = string(b)
+	case len(args) == 1:
+		kql = args[0]
+	}
+
+	if strings.TrimSpace(kql) == "" {
+		return "", errors.New("no query given; pass it as an argument or with --file")
+	}
+	return kql, nil
+}
+
+// idColumn returns the values of the id column of table.
+func idColumn(table *azure.Table) ([]string, error) {
+	for i, c := range table.Columns {
+		if !strings.EqualFold(c, "id") {
+			continue
+		}
+		ids := make([]string, 0, len(table.Rows))
+		for _, row := range table.Rows {
+			if id := output.Cell(row[i]); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+	return nil, errors.New("--pick needs an id column; add it to the query, e.g. `| project id, name`")
+}
+
+func init() {
+	queryCmd.Flags().StringVarP(&queryFile, "file", "f", "", "Read the query from a file, or stdin for -")
+	queryCmd.Flags().StringVarP(&queryOutput, "output", "o", output.Table, "Output format: "+strings.Join(output.Formats, ", "))
+	queryCmd.Flags().BoolVar(&queryPick, "pick", false, "Show rows with an id column in the picker")
+	queryCmd.Flags().StringSliceVar(&querySubscriptions, "subscription", nil, "Subscription IDs to query; default all accessible")
+	queryCmd.Flags().IntVar(&queryLimit, "limit", defaultQueryLimit, "Most rows to return; 0 for all")
+
+	_ = queryCmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return output.Formats, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	rootCmd.AddCommand(queryCmd)
+}
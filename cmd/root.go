@@ -2,12 +2,20 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"github.com/chege/azfind/internal/actions"
+	"github.com/chege/azfind/internal/clipboard"
 	"github.com/chege/azfind/internal/completion"
 	"github.com/chege/azfind/internal/fzfui"
+	"github.com/chege/azfind/internal/picker"
+	"github.com/chege/azfind/internal/saved"
 	"github.com/chege/azfind/internal/syncer"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -17,6 +25,8 @@ var cfgFile string
 var listCache bool
 var doSync bool
 var doCompletion bool
+var copyField string
+var noBrowser bool
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -25,7 +35,8 @@ var rootCmd = &cobra.Command{
 	Long:  `"azf" is a fast CLI for searching, filtering, and opening Azure resources in the browser or running supported actions.`,
 	Args:  cobra.ArbitraryArgs, // allow arbitrary args for search input
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx, stop := signalContext()
+		defer stop()
 
 		if doCompletion {
 			shell := "bash"
@@ -36,17 +47,180 @@ var rootCmd = &cobra.Command{
 		}
 
 		if doSync {
-			return syncer.SyncAll(ctx)
+			return syncer.SyncAll(ctx, syncer.NewReporter(os.Stdout), syncOptions())
 		}
 
 		if listCache {
 			return nil // list-cache will be reimplemented later
 		}
 
-		return fzfui.RunSearch(ctx, args)
+		if name, extra, ok := saved.FromArgs(args); ok {
+			return runSaved(ctx, cmd, name, extra)
+		}
+
+		opts, err := searchOptions("")
+		if err != nil {
+			return err
+		}
+		return runSearch(ctx, cmd, args, opts)
 	},
 }
 
+// Exit codes that let scripts tell why a search ended without a selection.
+const (
+	exitFailure           = 1
+	exitNoMatch           = 2
+	exitPickerUnavailable = 3
+	exitCancelled         = 130
+)
+
+// runSearch runs the search and explains picker outcomes with a hint instead
+// of cobra's error and usage output.
+func runSearch(ctx context.Context, cmd *cobra.Command, args []string, opts fzfui.SearchOptions) error {
+	return pickerError(cmd, fzfui.RunSearch(ctx, args, opts))
+}
+
+// pickerError prints a hint for the picker errors and keeps cobra from
+// reporting them again; other errors are returned as they are.
+func pickerError(cmd *cobra.Command, err error) error {
+	switch {
+	case errors.Is(err, picker.ErrCancelled):
+	case errors.Is(err, picker.ErrNoMatch):
+		_, _ = fmt.Fprintln(os.Stderr, "No cached resources match the query. Run `azf sync` to refresh.")
+	case errors.Is(err, picker.ErrPickerUnavailable):
+		_, _ = fmt.Fprintf(os.Stderr, "%v. Install fzf or set `ui: builtin` in ~/.azfind.yaml.\n", err)
+	default:
+		return err
+	}
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return err
+}
+
+// exitCode maps an error returned by a command to the process exit code.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, picker.ErrCancelled):
+		return exitCancelled
+	case errors.Is(err, picker.ErrNoMatch):
+		return exitNoMatch
+	case errors.Is(err, picker.ErrPickerUnavailable):
+		return exitPickerUnavailable
+	default:
+		return exitFailure
+	}
+}
+
+// searchOptions builds picker options from configuration and the --copy flag.
+// A non-empty blade makes opening land on that portal blade.
+func searchOptions(blade string) (fzfui.SearchOptions, error) {
+	reg, err := actionRegistry()
+	if err != nil {
+		return fzfui.SearchOptions{}, err
+	}
+
+	defaultAction, err := copyAction(copyField)
+	if err != nil {
+		return fzfui.SearchOptions{}, err
+	}
+	if blade != "" && defaultAction == actions.OpenPortal {
+		a := reg.OpenBlade(blade)
+		if _, ok := reg.Get(a.Name); !ok {
+			reg.Register(a)
+		}
+		defaultAction = a.Name
+	}
+
+	layout, err := pickerLayout()
+	if err != nil {
+		return fzfui.SearchOptions{}, err
+	}
+
+	return fzfui.SearchOptions{
+		MaxAge:        viper.GetDuration("cache.max_age"),
+		AutoRefresh:   viper.GetBool("cache.auto_refresh"),
+		Actions:       reg,
+		MaxTabs:       viper.GetInt("picker.max_tabs"),
+		DefaultAction: defaultAction,
+		UI:            viper.GetString("ui"),
+		Preview:       previewCommand(),
+		Layout:        layout,
+		PreferTypes:   viper.GetStringSlice("picker.prefer_types"),
+	}, nil
+}
+
+// typeColor is an entry of "picker.colors"; a list for the same reason as
+// bladeDefault.
+type typeColor struct {
+	Type  string `mapstructure:"type"`
+	Color string `mapstructure:"color"`
+}
+
+// pickerLayout reads the picker columns and colours from configuration.
+func pickerLayout() (picker.Layout, error) {
+	layout := picker.Layout{
+		Truncate: viper.GetString("picker.truncate"),
+		NoColor:  !viper.GetBool("picker.color") || os.Getenv("NO_COLOR") != "",
+	}
+	if err := viper.UnmarshalKey("picker.columns", &layout.Columns); err != nil {
+		return picker.Layout{}, fmt.Errorf("invalid picker.columns config: %w", err)
+	}
+
+	var colors []typeColor
+	if err := viper.UnmarshalKey("picker.colors", &colors); err != nil {
+		return picker.Layout{}, fmt.Errorf("invalid picker.colors config: %w", err)
+	}
+	layout.Colors = map[string]string{}
+	for _, c := range colors {
+		layout.Colors[strings.ToLower(c.Type)] = c.Color
+	}
+
+	if err := layout.Validate(); err != nil {
+		return picker.Layout{}, fmt.Errorf("invalid picker config: %w", err)
+	}
+	return layout, nil
+}
+
+// previewCommand is the command the picker runs to preview a resource, with
+// the resource ID appended. It is nil if the azf binary cannot be located.
+func previewCommand() []string {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil
+	}
+	args := []string{exe}
+	if cfgFile != "" {
+		args = append(args, "--config", cfgFile)
+	}
+	return append(args, "__preview")
+}
+
+// previewing reports whether azf was invoked as the preview command.
+func previewing() bool {
+	c, _, err := rootCmd.Find(os.Args[1:])
+	return err == nil && c == previewCmd
+}
+
+// copyAction maps the --copy flag to the action that replaces opening.
+func copyAction(field string) (string, error) {
+	switch field {
+	case "":
+		return actions.OpenPortal, nil
+	case "id":
+		return actions.CopyID, nil
+	case "url":
+		return actions.CopyURL, nil
+	default:
+		return "", fmt.Errorf("invalid --copy value %q (use id or url)", field)
+	}
+}
+
+// signalContext returns a context that is cancelled on SIGINT or SIGTERM so
+// long-running work can stop cleanly instead of being killed mid-write.
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
 // generateCompletionScript prints shell completion to w based on the given shell.
 func generateCompletionScript(cmd *cobra.Command, shell string, w io.Writer) error {
 	switch shell {
@@ -68,7 +242,7 @@ func generateCompletionScript(cmd *cobra.Command, shell string, w io.Writer) err
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -80,6 +254,7 @@ func init() {
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.azfind.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&noBrowser, "no-browser", false, "Print URLs instead of opening them in a browser")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -87,14 +262,31 @@ func init() {
 	rootCmd.Flags().BoolVar(&listCache, "list-cache", false, "List cached Azure resources")
 	rootCmd.Flags().BoolVar(&doSync, "sync", false, "Synchronize Azure resources into local cache")
 	rootCmd.Flags().BoolVar(&doCompletion, "completion", false, "Generate dynamic name completions")
+	rootCmd.Flags().StringVar(&copyField, "copy", "", "Copy the resource ID (--copy) or portal URL (--copy=url) instead of opening it")
+	rootCmd.Flags().Lookup("copy").NoOptDefVal = "id"
 
 	_ = rootCmd.Flags().MarkHidden("completion")
 
-	rootCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		ctx := context.Background()
-		list, _ := completion.Generate(ctx, toComplete)
-		return list, cobra.ShellCompDirectiveNoFileComp
+	viper.SetDefault("cache.max_age", "168h")
+	viper.SetDefault("cache.auto_refresh", false)
+	viper.SetDefault("picker.max_tabs", 10)
+	viper.SetDefault("picker.truncate", picker.TruncateEnd)
+	viper.SetDefault("picker.color", true)
+	viper.SetDefault("picker.prefer_types", picker.DefaultPreferTypes())
+	viper.SetDefault("ui", "auto")
+
+	rootCmd.ValidArgsFunction = completeResourceNames
+}
+
+// completeResourceNames completes cached resource names, and saved query
+// names after "@".
+func completeResourceNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if strings.HasPrefix(toComplete, saved.Prefix) {
+		return completeSaved(saved.Prefix), cobra.ShellCompDirectiveNoFileComp
 	}
+	ctx := context.Background()
+	list, _ := completion.Generate(ctx, toComplete)
+	return list, cobra.ShellCompDirectiveNoFileComp
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -115,8 +307,11 @@ func initConfig() {
 
 	viper.AutomaticEnv() // read in environment variables that match
 
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
+	// If a config file is found, read it in. The preview runs inside the
+	// picker's preview pane, where the notice would be noise.
+	if err := viper.ReadInConfig(); err == nil && !previewing() {
 		_, _ = fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
+
+	clipboard.SetTool(viper.GetString("clipboard.tool"))
 }
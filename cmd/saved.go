@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chege/azfind/internal/saved"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// savedCmd groups commands for the saved queries under "queries:".
+var savedCmd = &cobra.Command{
+	Use:   "saved",
+	Short: "List and run saved queries",
+	Long: `Saved queries are named searches defined under "queries:" in the config file,
+either in the search syntax (run against the cache) or as KQL (run live
+against Resource Graph). "azf @<name>" is short for "azf saved run <name>".`,
+}
+
+var savedListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the saved queries",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		queries, err := savedQueries()
+		if err != nil {
+			return err
+		}
+		if len(queries) == 0 {
+			fmt.Println(`No saved queries. Define them under "queries:" in ~/.azfind.yaml.`)
+			return nil
+		}
+
+		tbl := table.New("Name", "Kind", "Query", "Description").WithWriter(os.Stdout)
+		for _, q := range queries {
+			tbl.AddRow(q.Name, q.Kind(), q.Text(), q.Description)
+		}
+		tbl.Print()
+		return nil
+	},
+}
+
+var savedRunCmd = &cobra.Command{
+	Use:   "run <name> [terms...]",
+	Short: "Run a saved query",
+	Long: `Run a saved query. A search runs against the cache like "azf <search>", with
+any further terms appended; KQL runs live and shows the rows with an id column
+in the picker, or prints them if the query sets "output".`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signalContext()
+		defer stop()
+		return runSaved(ctx, cmd, strings.TrimPrefix(args[0], saved.Prefix), args[1:])
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeSaved(""), cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+// savedQueries reads and checks "queries:" from configuration; a list for
+// the same reason as bladeDefault.
+func savedQueries() ([]saved.Query, error) {
+	var queries []saved.Query
+	if err := viper.UnmarshalKey("queries", &queries); err != nil {
+		return nil, fmt.Errorf("invalid queries config: %w", err)
+	}
+	if err := saved.Validate(queries); err != nil {
+		return nil, fmt.Errorf("invalid queries config: %w", err)
+	}
+	return queries, nil
+}
+
+// runSaved runs the saved query called name, with extra search terms.
+func runSaved(ctx context.Context, cmd *cobra.Command, name string, extra []string) error {
+	queries, err := savedQueries()
+	if err != nil {
+		return err
+	}
+	q, err := saved.Find(queries, name)
+	if err != nil {
+		return fmt.Errorf("%w; see `azf saved list`", err)
+	}
+	terms, err := q.Terms(extra)
+	if err != nil {
+		return err
+	}
+
+	if q.KQL != "" {
+		return runQuery(ctx, cmd, liveQuery{
+			KQL:    q.KQL,
+			Limit:  defaultQueryLimit,
+			Output: q.Output,
+			Pick:   q.Output == "",
+		})
+	}
+	opts, err := searchOptions("")
+	if err != nil {
+		return err
+	}
+	return runSearch(ctx, cmd, terms, opts)
+}
+
+// completeSaved returns the names of the saved queries with prefix, and
+// their descriptions for shells that show them.
+func completeSaved(prefix string) []string {
+	queries, err := savedQueries()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, q := range queries {
+		desc := q.Description
+		if desc == "" {
+			desc = q.Text()
+		}
+		names = append(names, prefix+q.Name+"\t"+desc)
+	}
+	return names
+}
+
+func init() {
+	savedCmd.AddCommand(savedListCmd, savedRunCmd)
+	rootCmd.AddCommand(savedCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/chege/azfind/internal/azure"
+	"github.com/chege/azfind/internal/syncer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var syncResume bool
+
+// syncCmd refreshes the local cache; it is equivalent to "azf --sync".
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Synchronize Azure resources into local cache",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signalContext()
+		defer stop()
+		return syncer.SyncAll(ctx, syncer.NewReporter(os.Stdout), syncOptions())
+	},
+}
+
+// syncStatusCmd shows when each subscription was last synced.
+var syncStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the last successful sync per subscription",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signalContext()
+		defer stop()
+		return syncer.PrintStatus(ctx, os.Stdout)
+	},
+}
+
+// syncOptions builds syncer options from flags and configuration.
+func syncOptions() syncer.Options {
+	return syncer.Options{
+		Resume:      syncResume,
+		Retry:       retryConfig(),
+		RetryFailed: viper.GetBool("sync.retry_failed"),
+	}
+}
+
+// retryConfig reads the retry policy for Azure requests from configuration.
+func retryConfig() azure.RetryConfig {
+	return azure.RetryConfig{
+		MaxRetries:  viper.GetInt("azure.retry.max_retries"),
+		Delay:       viper.GetDuration("azure.retry.delay"),
+		MaxDelay:    viper.GetDuration("azure.retry.max_delay"),
+		TryTimeout:  viper.GetDuration("azure.retry.try_timeout"),
+		StatusCodes: viper.GetIntSlice("azure.retry.status_codes"),
+	}
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&syncResume, "resume", false, "Continue the last cancelled or failed sync with the remaining subscriptions")
+	defaults := azure.DefaultRetryConfig()
+	viper.SetDefault("azure.retry.max_retries", defaults.MaxRetries)
+	viper.SetDefault("azure.retry.delay", defaults.Delay)
+	viper.SetDefault("azure.retry.max_delay", defaults.MaxDelay)
+	viper.SetDefault("sync.retry_failed", true)
+
+	syncCmd.AddCommand(syncStatusCmd)
+	rootCmd.AddCommand(syncCmd)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/chege/azfind/internal/cache"
+	"github.com/chege/azfind/internal/fzfui"
+	"github.com/chege/azfind/internal/hierarchy"
+	"github.com/spf13/cobra"
+)
+
+var treePrint bool
+
+// treeCmd browses management groups, subscriptions, resource groups and
+// resources level by level, or prints the hierarchy with resource counts.
+var treeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Browse management groups, subscriptions and resource groups",
+	Long: `Browse the cached resources level by level: management groups, subscriptions,
+resource groups, resources. Enter drills down, esc goes back and ctrl-o opens
+the highlighted level in the portal. With --print, or when stdout is not a
+terminal, the hierarchy is printed with resource counts instead.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signalContext()
+		defer stop()
+
+		if treePrint || !term.IsTerminal(os.Stdout.Fd()) {
+			return printTree(ctx)
+		}
+
+		opts, err := searchOptions("")
+		if err != nil {
+			return err
+		}
+		return pickerError(cmd, fzfui.RunBrowse(ctx, opts))
+	},
+}
+
+// printTree writes the management group hierarchy with resource counts.
+func printTree(ctx context.Context) (err error) {
+	db, err := cache.Open(ctx)
+	if err != nil {
+		return fmt.Errorf("open cache: %w", err)
+	}
+	defer func() {
+		if cerr := db.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close cache db: %w", cerr)
+		}
+	}()
+
+	roots, err := hierarchy.Load(ctx, db)
+	if err != nil {
+		return err
+	}
+	if len(roots) == 0 {
+		fmt.Println("No cached subscriptions found. Run `azf sync` first.")
+		return nil
+	}
+	hierarchy.Print(os.Stdout, roots)
+	return nil
+}
+
+func init() {
+	treeCmd.Flags().BoolVar(&treePrint, "print", false, "Print the hierarchy instead of browsing it")
+	rootCmd.AddCommand(treeCmd)
+}
@@ -0,0 +1,165 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chege/azfind/internal/browser"
+	"github.com/chege/azfind/internal/cache"
+)
+
+// Action is something azf can do with a selected resource.
+type Action struct {
+	// Name is the stable identifier used in config and on the command line, e.g. "copy-id".
+	Name string
+	// Description is shown in the action menu.
+	Description string
+	// Key is the picker key binding, e.g. "ctrl-y". Empty means menu only.
+	Key string
+	// Types limits the action to resources of these types, matched
+	// case-insensitively against cache.Resource.Type. Empty means all types.
+	Types []string
+	// Run performs the action.
+	Run func(ctx context.Context, r cache.Resource) error
+	// RunAll, if set, handles several resources at once, e.g. copying all IDs
+	// as one clipboard entry. Otherwise Run is called for each resource.
+	RunAll func(ctx context.Context, rs []cache.Resource) error
+	// OpensBrowser marks actions that open a browser tab per resource so bulk
+	// runs can be capped and confirmed.
+	OpensBrowser bool
+}
+
+// AppliesTo reports whether the action can run on a resource of resourceType.
+func (a Action) AppliesTo(resourceType string) bool {
+	if len(a.Types) == 0 {
+		return true
+	}
+	for _, t := range a.Types {
+		if strings.EqualFold(t, resourceType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Opener opens URLs; the tenant, identified by any of its ID and domain,
+// selects a browser profile. *browser.Launcher implements it.
+type Opener interface {
+	Open(url string, tenants ...string) error
+}
+
+// Registry holds the actions available in the picker.
+type Registry struct {
+	actions map[string]Action
+	order   []string
+	browser Opener
+}
+
+// NewRegistry returns a registry containing the given actions. Actions added
+// later that open URLs use the default browser.
+func NewRegistry(actions ...Action) *Registry {
+	reg := &Registry{actions: map[string]Action{}, browser: browser.New(browser.Config{})}
+	for _, a := range actions {
+		reg.Register(a)
+	}
+	return reg
+}
+
+// Register adds a, replacing any action with the same name.
+func (reg *Registry) Register(a Action) {
+	if _, ok := reg.actions[a.Name]; !ok {
+		reg.order = append(reg.order, a.Name)
+	}
+	reg.actions[a.Name] = a
+}
+
+// Get returns the action called name.
+func (reg *Registry) Get(name string) (Action, bool) {
+	a, ok := reg.actions[name]
+	return a, ok
+}
+
+// ByKey returns the action bound to key.
+func (reg *Registry) ByKey(key string) (Action, bool) {
+	for _, name := range reg.order {
+		if a := reg.actions[name]; a.Key != "" && a.Key == key {
+			return a, true
+		}
+	}
+	return Action{}, false
+}
+
+// List returns all actions in registration order.
+func (reg *Registry) List() []Action {
+	list := make([]Action, 0, len(reg.order))
+	for _, name := range reg.order {
+		list = append(list, reg.actions[name])
+	}
+	return list
+}
+
+// For returns the actions that apply to r, in registration order.
+func (reg *Registry) For(r cache.Resource) []Action {
+	var list []Action
+	for _, a := range reg.List() {
+		if a.AppliesTo(r.Type) {
+			list = append(list, a)
+		}
+	}
+	return list
+}
+
+// ForAll returns the actions that apply to every resource in rs.
+func (reg *Registry) ForAll(rs []cache.Resource) []Action {
+	var list []Action
+	for _, a := range reg.List() {
+		ok := true
+		for _, r := range rs {
+			if !a.AppliesTo(r.Type) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			list = append(list, a)
+		}
+	}
+	return list
+}
+
+// Keys returns the key bindings of all actions, sorted.
+func (reg *Registry) Keys() []string {
+	var keys []string
+	for _, a := range reg.actions {
+		if a.Key != "" {
+			keys = append(keys, a.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RunOn runs a on every resource in rs, using RunAll when there is more than
+// one. Failures of individual resources are joined; the others still run.
+func RunOn(ctx context.Context, a Action, rs []cache.Resource) error {
+	for _, r := range rs {
+		if !a.AppliesTo(r.Type) {
+			return fmt.Errorf("action %q does not apply to %s (%s)", a.Name, r.Name, r.Type)
+		}
+	}
+
+	if a.RunAll != nil && len(rs) > 1 {
+		return a.RunAll(ctx, rs)
+	}
+
+	var errs []error
+	for _, r := range rs {
+		if err := a.Run(ctx, r); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", a.Name, r.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
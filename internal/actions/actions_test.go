@@ -0,0 +1,278 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chege/azfind/internal/cache"
+)
+
+func TestRegistryLookup(t *testing.T) {
+	reg := Default(Options{})
+
+	if a, ok := reg.ByKey("ctrl-y"); !ok || a.Name != CopyID {
+		t.Fatalf("expected ctrl-y to copy the id, got %+v", a)
+	}
+	if _, ok := reg.ByKey("ctrl-z"); ok {
+		t.Fatal("expected no action for unbound key")
+	}
+	if _, ok := reg.Get(AzSnippet); !ok {
+		t.Fatal("expected az-cli action to be registered")
+	}
+
+	want := []string{"ctrl-o", "ctrl-u", "ctrl-y"}
+	if got := reg.Keys(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected keys %v, got %v", want, got)
+	}
+}
+
+func TestRegisterReplacesByName(t *testing.T) {
+	reg := NewRegistry(Action{Name: "a", Key: "ctrl-x"}, Action{Name: "b"})
+	reg.Register(Action{Name: "a", Description: "replaced"})
+
+	list := reg.List()
+	if len(list) != 2 || list[0].Name != "a" || list[0].Description != "replaced" {
+		t.Fatalf("expected replacement to keep order, got %+v", list)
+	}
+	if _, ok := reg.ByKey("ctrl-x"); ok {
+		t.Fatal("expected old key binding to be gone")
+	}
+}
+
+func TestPortalURL(t *testing.T) {
+	r := cache.Resource{
+		ID:       "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1",
+		TenantID: "tenant1",
+	}
+	want := "https://portal.azure.com/#@tenant1/resource/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1"
+	if got := PortalURL(r); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	r.TenantDomain = "contoso.onmicrosoft.com"
+	want = "https://portal.azure.com/#@contoso.onmicrosoft.com/resource/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1"
+	if got := PortalURL(r); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestForFiltersByType(t *testing.T) {
+	reg := Default(Options{})
+
+	names := func(list []Action) map[string]bool {
+		m := map[string]bool{}
+		for _, a := range list {
+			m[a.Name] = true
+		}
+		return m
+	}
+
+	kv := names(reg.For(cache.Resource{Type: "Microsoft.KeyVault/vaults"}))
+	if !kv["kv-secrets"] || !kv[OpenPortal] {
+		t.Fatalf("expected key vault to get kv-secrets and open, got %v", kv)
+	}
+	if kv["vm-ssh"] || kv["aks-credentials"] {
+		t.Fatalf("expected no VM or AKS actions for a key vault, got %v", kv)
+	}
+
+	vm := names(reg.For(cache.Resource{Type: "microsoft.compute/virtualmachines"}))
+	if !vm["vm-ssh"] || vm["kv-secrets"] {
+		t.Fatalf("unexpected VM actions: %v", vm)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	r := cache.Resource{
+		ID:             "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1",
+		Name:           "kv1",
+		Type:           "microsoft.keyvault/vaults",
+		ResourceGroup:  "rg1",
+		SubscriptionID: "sub1",
+		TenantID:       "tenant1",
+	}
+
+	got := Expand("az keyvault secret list --vault-name {name} --subscription {subscriptionId}", r)
+	if want := "az keyvault secret list --vault-name kv1 --subscription sub1"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got := Expand("{portalUrl}/overview", r); got != PortalURL(r)+"/overview" {
+		t.Fatalf("unexpected portal expansion %q", got)
+	}
+
+	reg := Default(Options{})
+	err := reg.RegisterConfig([]Config{{
+		Name:    "kv-list",
+		Key:     "ctrl-k",
+		Types:   []string{"Microsoft.KeyVault/vaults"},
+		Command: "az keyvault secret list --vault-name {name}",
+	}}, testReserved)
+	if err != nil {
+		t.Fatalf("register config: %v", err)
+	}
+	a, ok := reg.ByKey("ctrl-k")
+	if !ok || a.Name != "kv-list" || !a.AppliesTo(r.Type) || a.AppliesTo("microsoft.web/sites") {
+		t.Fatalf("unexpected configured action %+v", a)
+	}
+
+	for _, bad := range []Config{
+		{Command: "echo"},
+		{Name: "both", Command: "echo", URL: "https://example.com"},
+		{Name: "neither"},
+		{Name: "blank", Command: "  \t "},
+	} {
+		if _, err := FromConfig(bad, nil); err == nil {
+			t.Errorf("expected error for %+v", bad)
+		}
+	}
+	if err := Default(Options{}).RegisterConfig([]Config{{Name: "children", Command: "echo"}}, testReserved); err == nil {
+		t.Error("expected error for an action named like a picker pseudo-action")
+	}
+}
+
+// testReserved mirrors picker.Reserved, which cannot be imported here.
+var testReserved = Reserved{Keys: []string{"ctrl-a", "ctrl-b", "ctrl-t"}, Names: []string{"children"}}
+
+func TestRegisterConfigKeyClashes(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		configs []Config
+		wantErr bool
+	}{
+		{"free key", []Config{{Name: "kv", Key: "ctrl-k", Command: "echo"}}, false},
+		{"built-in key", []Config{{Name: "kv", Key: "ctrl-y", Command: "echo"}}, true},
+		{"reserved key", []Config{{Name: "kv", Key: "ctrl-a", Command: "echo"}}, true},
+		{"key of an earlier entry", []Config{{Name: "a", Key: "ctrl-k", Command: "echo"}, {Name: "b", Key: "ctrl-k", Command: "echo"}}, true},
+		{"replacing a built-in keeps its key", []Config{{Name: CopyID, Key: "ctrl-y", Command: "echo"}}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Default(Options{}).RegisterConfig(tc.configs, testReserved)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRunOn(t *testing.T) {
+	rs := []cache.Resource{
+		{Name: "a", Type: "microsoft.web/sites"},
+		{Name: "b", Type: "microsoft.web/sites"},
+	}
+
+	var single []string
+	each := Action{
+		Name: "each",
+		Run: func(ctx context.Context, r cache.Resource) error {
+			single = append(single, r.Name)
+			if r.Name == "a" {
+				return errors.New("boom")
+			}
+			return nil
+		},
+	}
+	err := RunOn(context.Background(), each, rs)
+	if err == nil || !strings.Contains(err.Error(), "each a: boom") {
+		t.Fatalf("expected joined error for a, got %v", err)
+	}
+	if !reflect.DeepEqual(single, []string{"a", "b"}) {
+		t.Fatalf("expected every resource to run despite the failure, got %v", single)
+	}
+
+	var bulk int
+	all := Action{
+		Name: "all",
+		Run: func(ctx context.Context, r cache.Resource) error {
+			t.Fatal("Run must not be used for bulk")
+			return nil
+		},
+		RunAll: func(ctx context.Context, rs []cache.Resource) error { bulk = len(rs); return nil },
+	}
+	if err := RunOn(context.Background(), all, rs); err != nil || bulk != 2 {
+		t.Fatalf("expected RunAll with 2 resources, got %d (%v)", bulk, err)
+	}
+
+	typed := Action{Name: "typed", Types: []string{"microsoft.keyvault/vaults"}, Run: each.Run}
+	if err := RunOn(context.Background(), typed, rs); err == nil {
+		t.Fatal("expected error for action of another type")
+	}
+
+	if got := Default(Options{}).ForAll(append(rs, cache.Resource{Type: TypeKeyVault})); len(got) == 0 {
+		t.Fatal("expected generic actions to apply to mixed selection")
+	} else {
+		for _, a := range got {
+			if len(a.Types) > 0 {
+				t.Fatalf("expected only generic actions for mixed types, got %s", a.Name)
+			}
+		}
+	}
+}
+
+func TestBladeActions(t *testing.T) {
+	reg := Default(Options{})
+
+	if got := BladeAction("IAM"); got != "open-iam" {
+		t.Fatalf("expected open-iam, got %s", got)
+	}
+	if a, ok := reg.Get(BladeAction("iam")); !ok || !a.OpensBrowser || a.Description != "Open Access control (IAM)" {
+		t.Fatalf("expected iam blade action to be registered, got %+v", a)
+	}
+	if got := reg.OpenBlade("appServiceLogs").Name; got != "open-appServiceLogs" {
+		t.Fatalf("expected raw blade action name, got %s", got)
+	}
+}
+
+type recordingOpener struct {
+	urls    []string
+	tenants []string
+}
+
+func (o *recordingOpener) Open(url string, tenants ...string) error {
+	o.urls = append(o.urls, url)
+	o.tenants = append(o.tenants, tenants[0])
+	return nil
+}
+
+func TestOpenUsesBrowserAndDefaultBlade(t *testing.T) {
+	rec := &recordingOpener{}
+	reg := Default(Options{
+		Browser:       rec,
+		DefaultBlades: map[string]string{"microsoft.web/sites": "logs"},
+	})
+	if err := reg.RegisterConfig([]Config{{Name: "metrics", URL: "{portalUrl}/metrics"}}, Reserved{}); err != nil {
+		t.Fatalf("register config: %v", err)
+	}
+
+	site := cache.Resource{ID: "/subscriptions/s/resourceGroups/rg/providers/Microsoft.Web/sites/app", Type: "Microsoft.Web/sites", TenantID: "t1"}
+	vault := cache.Resource{ID: "/subscriptions/s/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv", Type: TypeKeyVault, TenantID: "t2"}
+
+	for _, step := range []struct {
+		action string
+		r      cache.Resource
+	}{
+		{OpenPortal, site},
+		{OpenPortal, vault},
+		{BladeAction("iam"), vault},
+		{"metrics", site},
+	} {
+		a, _ := reg.Get(step.action)
+		if err := a.Run(context.Background(), step.r); err != nil {
+			t.Fatalf("%s: %v", step.action, err)
+		}
+	}
+
+	want := []string{
+		PortalURL(site) + "/logs",
+		PortalURL(vault),
+		PortalURL(vault) + "/users",
+		PortalURL(site) + "/metrics",
+	}
+	if !reflect.DeepEqual(rec.urls, want) {
+		t.Fatalf("expected urls %q, got %q", want, rec.urls)
+	}
+	if !reflect.DeepEqual(rec.tenants, []string{"t1", "t2", "t2", "t1"}) {
+		t.Fatalf("expected tenant per resource, got %q", rec.tenants)
+	}
+}
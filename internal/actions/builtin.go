@@ -0,0 +1,189 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/chege/azfind/internal/browser"
+	"github.com/chege/azfind/internal/cache"
+	"github.com/chege/azfind/internal/clipboard"
+	"github.com/chege/azfind/internal/portal"
+)
+
+// Names of the built-in actions.
+const (
+	OpenPortal = "open"
+	CopyID     = "copy-id"
+	CopyURL    = "copy-url"
+	AzSnippet  = "az-cli"
+)
+
+// Resource types with built-in actions, as stored by Resource Graph.
+const (
+	TypeKeyVault       = "microsoft.keyvault/vaults"
+	TypeStorageAccount = "microsoft.storage/storageaccounts"
+	TypeAKS            = "microsoft.containerservice/managedclusters"
+	TypeVM             = "microsoft.compute/virtualmachines"
+	TypeWebSite        = "microsoft.web/sites"
+)
+
+// BladePrefix starts the names of actions that open a portal blade, e.g. "open-iam".
+const BladePrefix = "open-"
+
+// BladeAction returns the name of the action that opens blade.
+func BladeAction(blade string) string {
+	return BladePrefix + portal.LookupBlade(blade).Name
+}
+
+// Options configures the built-in actions.
+type Options struct {
+	// DefaultBlades maps lower-case resource types to the blade the open
+	// action lands on instead of the overview, e.g. "microsoft.web/sites": "logs".
+	DefaultBlades map[string]string
+	// Browser opens portal URLs. Nil uses the platform default browser.
+	Browser Opener
+}
+
+// Default returns a registry with the built-in actions.
+func Default(opts Options) *Registry {
+	open := opts.Browser
+	if open == nil {
+		open = browser.New(browser.Config{})
+	}
+
+	reg := NewRegistry(
+		Action{
+			Name:        OpenPortal,
+			Description: "Open in the Azure Portal",
+			Key:         "ctrl-o",
+			Run: func(ctx context.Context, r cache.Resource) error {
+				return open.Open(portal.ResourceURL(r.PortalTenant(), r.ID, opts.DefaultBlades[strings.ToLower(r.Type)]), r.TenantID, r.TenantDomain)
+			},
+			OpensBrowser: true,
+		},
+		Action{
+			Name:        CopyID,
+			Description: "Copy resource ID",
+			Key:         "ctrl-y",
+			Run: func(ctx context.Context, r cache.Resource) error {
+				return copyAndReport("resource ID", r.ID)
+			},
+			RunAll: func(ctx context.Context, rs []cache.Resource) error {
+				return copyAndReport("resource IDs", joinLines(rs, func(r cache.Resource) string { return r.ID }))
+			},
+		},
+		Action{
+			Name:        CopyURL,
+			Description: "Copy portal URL",
+			Key:         "ctrl-u",
+			Run: func(ctx context.Context, r cache.Resource) error {
+				return copyAndReport("portal URL", PortalURL(r))
+			},
+			RunAll: func(ctx context.Context, rs []cache.Resource) error {
+				return copyAndReport("portal URLs", joinLines(rs, PortalURL))
+			},
+		},
+		Action{
+			Name:        AzSnippet,
+			Description: "Print an az CLI command for the resource",
+			Run: func(ctx context.Context, r cache.Resource) error {
+				fmt.Println(AzCLISnippet(r))
+				return nil
+			},
+		},
+		typeBladeAction(open, "kv-secrets", "Open Key Vault secrets", "secrets", TypeKeyVault),
+		typeBladeAction(open, "storage-containers", "Open storage account containers", "containersList", TypeStorageAccount),
+		typeBladeAction(open, "app-logs", "Open App Service log stream", "logStream", TypeWebSite),
+		Action{
+			Name:        "aks-credentials",
+			Description: "Merge cluster credentials into kubeconfig (az aks get-credentials)",
+			Types:       []string{TypeAKS},
+			Run: func(ctx context.Context, r cache.Resource) error {
+				return runInteractive(ctx, "az", "aks", "get-credentials",
+					"--subscription", r.SubscriptionID,
+					"--resource-group", r.ResourceGroup,
+					"--name", r.Name)
+			},
+		},
+		Action{
+			Name:        "vm-ssh",
+			Description: "SSH into the VM (az ssh vm)",
+			Types:       []string{TypeVM},
+			Run: func(ctx context.Context, r cache.Resource) error {
+				return runInteractive(ctx, "az", "ssh", "vm", "--ids", r.ID)
+			},
+		},
+	)
+	reg.browser = open
+	for _, b := range portal.Blades {
+		reg.Register(reg.OpenBlade(b.Name))
+	}
+	return reg
+}
+
+// OpenBlade returns an action that opens blade on any resource with the
+// registry's browser. Blades not in portal.Blades are used as raw portal path
+// segments.
+func (reg *Registry) OpenBlade(blade string) Action {
+	return openBlade(reg.browser, blade)
+}
+
+func openBlade(open Opener, blade string) Action {
+	b := portal.LookupBlade(blade)
+	return Action{
+		Name:        BladeAction(b.Name),
+		Description: "Open " + b.Title,
+		Run: func(ctx context.Context, r cache.Resource) error {
+			return open.Open(portal.ResourceURL(r.PortalTenant(), r.ID, b.Path), r.TenantID, r.TenantDomain)
+		},
+		OpensBrowser: true,
+	}
+}
+
+// typeBladeAction opens a type-specific portal blade of resources of the given types.
+func typeBladeAction(open Opener, name, description, blade string, types ...string) Action {
+	a := openBlade(open, blade)
+	a.Name = name
+	a.Description = description
+	a.Types = types
+	return a
+}
+
+// PortalURL returns the Azure Portal overview URL of r, addressing the tenant
+// by its default domain when it is known.
+func PortalURL(r cache.Resource) string {
+	return portal.ResourceURL(r.PortalTenant(), r.ID, "")
+}
+
+// AzCLISnippet returns an az CLI command that shows r.
+func AzCLISnippet(r cache.Resource) string {
+	return fmt.Sprintf("az resource show --ids %q", r.ID)
+}
+
+// runInteractive runs a command attached to the terminal.
+func runInteractive(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func copyAndReport(what, text string) error {
+	if err := clipboard.Copy(text); err != nil {
+		return fmt.Errorf("copy %s: %w", what, err)
+	}
+	fmt.Printf("Copied %s:\n%s\n", what, text)
+	return nil
+}
+
+func joinLines(rs []cache.Resource, field func(cache.Resource) string) string {
+	lines := make([]string, len(rs))
+	for i, r := range rs {
+		lines[i] = field(r)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,119 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chege/azfind/internal/cache"
+)
+
+// Config is a user-defined action from the "actions:" section of the config file.
+// Exactly one of Command and URL must be set. Both may use the placeholders
+// {id}, {name}, {type}, {resourceGroup}, {subscriptionId}, {location},
+// {tenantId} and {portalUrl}.
+type Config struct {
+	Name        string   `mapstructure:"name"`
+	Description string   `mapstructure:"description"`
+	Key         string   `mapstructure:"key"`
+	Types       []string `mapstructure:"types"`
+	Command     string   `mapstructure:"command"`
+	URL         string   `mapstructure:"url"`
+}
+
+// FromConfig turns a user-defined action into an Action. URL actions open
+// with browser.
+func FromConfig(c Config, browser Opener) (Action, error) {
+	if c.Name == "" {
+		return Action{}, errors.New("action without name")
+	}
+	if (c.Command == "") == (c.URL == "") {
+		return Action{}, fmt.Errorf("action %q: set exactly one of command or url", c.Name)
+	}
+	if c.URL == "" && len(strings.Fields(c.Command)) == 0 {
+		return Action{}, fmt.Errorf("action %q: command is blank", c.Name)
+	}
+
+	a := Action{
+		Name:        c.Name,
+		Description: c.Description,
+		Key:         c.Key,
+		Types:       c.Types,
+	}
+	if a.Description == "" {
+		a.Description = c.Command + c.URL
+	}
+
+	if c.URL != "" {
+		tmpl := c.URL
+		a.Run = func(ctx context.Context, r cache.Resource) error {
+			return browser.Open(Expand(tmpl, r), r.TenantID, r.TenantDomain)
+		}
+		a.OpensBrowser = true
+		return a, nil
+	}
+
+	// Split before expanding so names with spaces stay a single argument.
+	argv := strings.Fields(c.Command)
+	a.Run = func(ctx context.Context, r cache.Resource) error {
+		args := make([]string, len(argv))
+		for i, arg := range argv {
+			args[i] = Expand(arg, r)
+		}
+		return runInteractive(ctx, args[0], args[1:]...)
+	}
+	return a, nil
+}
+
+// Reserved are the keys and action names a caller such as the picker handles
+// itself, so configured actions may not use them.
+type Reserved struct {
+	Keys  []string
+	Names []string
+}
+
+// RegisterConfig adds user-defined actions to reg, replacing built-ins of the
+// same name. Names and keys may not be reserved, and a key may not be bound
+// to another action already.
+func (reg *Registry) RegisterConfig(configs []Config, reserved Reserved) error {
+	for _, c := range configs {
+		a, err := FromConfig(c, reg.browser)
+		if err != nil {
+			return err
+		}
+		for _, n := range reserved.Names {
+			if a.Name == n {
+				return fmt.Errorf("action name %q is reserved by the picker", a.Name)
+			}
+		}
+		if a.Key != "" {
+			for _, k := range reserved.Keys {
+				if strings.EqualFold(a.Key, k) {
+					return fmt.Errorf("action %q: key %s is reserved by the picker", a.Name, a.Key)
+				}
+			}
+			for _, other := range reg.List() {
+				if other.Name != a.Name && strings.EqualFold(other.Key, a.Key) {
+					return fmt.Errorf("action %q: key %s is already bound to %q", a.Name, a.Key, other.Name)
+				}
+			}
+		}
+		reg.Register(a)
+	}
+	return nil
+}
+
+// Expand replaces resource placeholders in tmpl.
+func Expand(tmpl string, r cache.Resource) string {
+	return strings.NewReplacer(
+		"{id}", r.ID,
+		"{name}", r.Name,
+		"{type}", r.Type,
+		"{resourceGroup}", r.ResourceGroup,
+		"{subscriptionId}", r.SubscriptionID,
+		"{location}", r.Location,
+		"{tenantId}", r.TenantID,
+		"{portalUrl}", PortalURL(r),
+	).Replace(tmpl)
+}
@@ -0,0 +1,215 @@
+// Package armid parses and formats Azure Resource Manager resource IDs such
+// as
+//
+//	/subscriptions/<sub>/resourceGroups/<rg>/providers/Microsoft.Sql/servers/<server>/databases/<db>
+package armid
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalid is wrapped by the errors Parse returns.
+var ErrInvalid = errors.New("invalid resource ID")
+
+// ID is a parsed resource ID. Scopes (a management group, a subscription or
+// a resource group) have no Provider; resources have a Provider and one name
+// per type segment.
+type ID struct {
+	// Scope is the resource an extension resource is attached to, e.g. the
+	// key vault of a role assignment. Nil for ordinary resources.
+	Scope *ID
+	// ManagementGroup is set on management group scopes and resources
+	// below them.
+	ManagementGroup string
+	SubscriptionID  string
+	ResourceGroup   string
+	// Provider is the resource provider namespace, e.g. "Microsoft.Sql".
+	Provider string
+	// Types are the resource type segments below the provider, outermost
+	// first, e.g. ["servers", "databases"].
+	Types []string
+	// Names are the resource names matching Types.
+	Names []string
+}
+
+// Parse parses a resource ID. Keywords such as "resourceGroups" are matched
+// case-insensitively; names and types keep their case.
+func Parse(s string) (ID, error) {
+	if !strings.HasPrefix(s, "/") {
+		return ID{}, fmt.Errorf("%w %q: must start with /", ErrInvalid, s)
+	}
+	segs := strings.Split(strings.Trim(s, "/"), "/")
+	for _, seg := range segs {
+		if seg == "" {
+			return ID{}, fmt.Errorf("%w %q: empty segment", ErrInvalid, s)
+		}
+	}
+
+	id, err := parse(segs)
+	if err != nil {
+		return ID{}, fmt.Errorf("%w %q: %v", ErrInvalid, s, err)
+	}
+	return id, nil
+}
+
+func parse(segs []string) (ID, error) {
+	var id ID
+	rest := segs
+	switch {
+	case len(rest) >= 2 && strings.EqualFold(rest[0], "subscriptions"):
+		id.SubscriptionID, rest = rest[1], rest[2:]
+		if len(rest) >= 1 && strings.EqualFold(rest[0], "resourceGroups") {
+			if len(rest) < 2 {
+				return ID{}, errors.New("missing resource group name")
+			}
+			id.ResourceGroup, rest = rest[1], rest[2:]
+		}
+	case len(rest) >= 4 && strings.EqualFold(rest[0], "providers") &&
+		strings.EqualFold(rest[1], "Microsoft.Management") && strings.EqualFold(rest[2], "managementGroups"):
+		id.ManagementGroup, rest = rest[3], rest[4:]
+	case len(rest) >= 2 && strings.EqualFold(rest[0], "providers"):
+		// Tenant-level resource, e.g. /providers/Microsoft.Billing/...
+	default:
+		return ID{}, errors.New("must start with /subscriptions/<id> or /providers/")
+	}
+
+	// Each providers/<namespace>/<type>/<name>... part is a resource; a
+	// further part makes it the scope of an extension resource.
+	for len(rest) > 0 {
+		if id.IsResource() {
+			scope := id
+			id = ID{Scope: &scope}
+		}
+		if !strings.EqualFold(rest[0], "providers") || len(rest) < 2 {
+			return ID{}, fmt.Errorf("expected providers/<namespace> at %q", strings.Join(rest, "/"))
+		}
+		id.Provider, rest = rest[1], rest[2:]
+		for len(rest) > 0 && !strings.EqualFold(rest[0], "providers") {
+			if len(rest) < 2 {
+				return ID{}, fmt.Errorf("missing name for resource type %q", rest[0])
+			}
+			id.Types = append(id.Types, rest[0])
+			id.Names = append(id.Names, rest[1])
+			rest = rest[2:]
+		}
+		if len(id.Types) == 0 {
+			return ID{}, fmt.Errorf("missing resource type for provider %q", id.Provider)
+		}
+	}
+	return id, nil
+}
+
+// String formats the ID with canonical keywords.
+func (id ID) String() string {
+	var b strings.Builder
+	if id.Scope != nil {
+		b.WriteString(id.Scope.String())
+	} else {
+		switch {
+		case id.ManagementGroup != "":
+			b.WriteString("/providers/Microsoft.Management/managementGroups/" + id.ManagementGroup)
+		case id.SubscriptionID != "":
+			b.WriteString("/subscriptions/" + id.SubscriptionID)
+			if id.ResourceGroup != "" {
+				b.WriteString("/resourceGroups/" + id.ResourceGroup)
+			}
+		}
+	}
+	if id.Provider != "" {
+		b.WriteString("/providers/" + id.Provider)
+		for i, t := range id.Types {
+			b.WriteString("/" + t + "/" + id.Names[i])
+		}
+	}
+	return b.String()
+}
+
+// IsResource reports whether the ID names a resource rather than a scope.
+func (id ID) IsResource() bool {
+	return id.Provider != ""
+}
+
+// Type returns the full resource type, e.g. "Microsoft.Sql/servers/databases".
+// Scopes return "".
+func (id ID) Type() string {
+	if !id.IsResource() {
+		return ""
+	}
+	return id.Provider + "/" + strings.Join(id.Types, "/")
+}
+
+// Name returns the name of the resource, or of the innermost scope.
+func (id ID) Name() string {
+	switch {
+	case len(id.Names) > 0:
+		return id.Names[len(id.Names)-1]
+	case id.ResourceGroup != "":
+		return id.ResourceGroup
+	case id.SubscriptionID != "":
+		return id.SubscriptionID
+	default:
+		return id.ManagementGroup
+	}
+}
+
+// Parent returns the resource a child resource is nested in, e.g. the SQL
+// server of a database, or the scope of an extension resource. It reports
+// false for top-level resources and scopes.
+func (id ID) Parent() (ID, bool) {
+	switch {
+	case len(id.Types) > 1:
+		parent := id
+		parent.Types = slices.Clone(id.Types[:len(id.Types)-1])
+		parent.Names = slices.Clone(id.Names[:len(id.Names)-1])
+		return parent, true
+	case id.Scope != nil && id.Scope.IsResource():
+		return *id.Scope, true
+	default:
+		return ID{}, false
+	}
+}
+
+// Ancestors returns the parent chain of the ID, nearest first.
+func (id ID) Ancestors() []ID {
+	var chain []ID
+	for p, ok := id.Parent(); ok; p, ok = p.Parent() {
+		chain = append(chain, p)
+	}
+	return chain
+}
+
+// ParentID returns the ID of the parent of the resource ID s, or "" if s
+// cannot be parsed or has no parent.
+func ParentID(s string) string {
+	id, err := Parse(s)
+	if err != nil {
+		return ""
+	}
+	if p, ok := id.Parent(); ok {
+		return p.String()
+	}
+	return ""
+}
+
+// Normalize parses s, ignoring surrounding space, and formats it again with
+// canonical keywords and without a trailing slash. Names keep their case, so
+// compare normalised IDs with strings.EqualFold.
+func Normalize(s string) (string, error) {
+	id, err := Parse(strings.TrimSpace(s))
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// ShortType returns the last segment of a resource type, e.g.
+// "Microsoft.App/containerApps" gives "containerApps".
+func ShortType(resourceType string) string {
+	if i := strings.LastIndex(resourceType, "/"); i >= 0 && i+1 < len(resourceType) {
+		return resourceType[i+1:]
+	}
+	return resourceType
+}
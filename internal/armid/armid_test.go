@@ -0,0 +1,181 @@
+package armid
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ID
+	}{
+		{"/subscriptions/sub1", ID{SubscriptionID: "sub1"}},
+		{"/subscriptions/sub1/resourceGroups/rg1", ID{SubscriptionID: "sub1", ResourceGroup: "rg1"}},
+		{"/providers/Microsoft.Management/managementGroups/corp", ID{ManagementGroup: "corp"}},
+		{
+			"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1",
+			ID{SubscriptionID: "sub1", ResourceGroup: "rg1", Provider: "Microsoft.KeyVault", Types: []string{"vaults"}, Names: []string{"kv1"}},
+		},
+		{
+			"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Sql/servers/sql1/databases/orders",
+			ID{SubscriptionID: "sub1", ResourceGroup: "rg1", Provider: "Microsoft.Sql", Types: []string{"servers", "databases"}, Names: []string{"sql1", "orders"}},
+		},
+		{
+			"/subscriptions/sub1/providers/Microsoft.Security/pricings/VirtualMachines",
+			ID{SubscriptionID: "sub1", Provider: "Microsoft.Security", Types: []string{"pricings"}, Names: []string{"VirtualMachines"}},
+		},
+		{
+			"/providers/Microsoft.Billing/billingAccounts/123",
+			ID{Provider: "Microsoft.Billing", Types: []string{"billingAccounts"}, Names: []string{"123"}},
+		},
+		{
+			"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1/providers/Microsoft.Authorization/roleAssignments/ra1",
+			ID{
+				Scope:    &ID{SubscriptionID: "sub1", ResourceGroup: "rg1", Provider: "Microsoft.KeyVault", Types: []string{"vaults"}, Names: []string{"kv1"}},
+				Provider: "Microsoft.Authorization", Types: []string{"roleAssignments"}, Names: []string{"ra1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if s := got.String(); s != tt.in {
+			t.Errorf("Parse(%q).String() = %q", tt.in, s)
+		}
+	}
+}
+
+func TestParseNormalisesKeywords(t *testing.T) {
+	id, err := Parse("/SUBSCRIPTIONS/sub1/resourcegroups/RG1/PROVIDERS/microsoft.web/sites/App1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/subscriptions/sub1/resourceGroups/RG1/providers/microsoft.web/sites/App1"; id.String() != want {
+		t.Fatalf("String() = %q, want %q", id.String(), want)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"subscriptions/sub1",
+		"/subscriptions",
+		"/subscriptions/sub1//resourceGroups/rg1",
+		"/subscriptions/sub1/resourceGroups",
+		"/subscriptions/sub1/resourceGroups/rg1/vaults/kv1",
+		"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault",
+		"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults",
+		"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Sql/servers/sql1/databases",
+		"/resourceGroups/rg1",
+		"https://portal.azure.com/",
+	} {
+		if _, err := Parse(in); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) = %v, want ErrInvalid", in, err)
+		}
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	id, err := Parse("/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !id.IsResource() || id.Type() != "Microsoft.Network/virtualNetworks/subnets" || id.Name() != "default" {
+		t.Fatalf("unexpected accessors: %v %q %q", id.IsResource(), id.Type(), id.Name())
+	}
+
+	rg, _ := Parse("/subscriptions/sub1/resourceGroups/rg1")
+	if rg.IsResource() || rg.Type() != "" || rg.Name() != "rg1" {
+		t.Fatalf("unexpected scope accessors: %v %q %q", rg.IsResource(), rg.Type(), rg.Name())
+	}
+}
+
+func TestParent(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1", nil},
+		{"/subscriptions/sub1/resourceGroups/rg1", nil},
+		{
+			"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Sql/servers/sql1/databases/orders",
+			[]string{"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Sql/servers/sql1"},
+		},
+		{
+			"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/st1/blobServices/default/containers/logs",
+			[]string{
+				"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/st1/blobServices/default",
+				"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/st1",
+			},
+		},
+		{
+			"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1/providers/Microsoft.Authorization/roleAssignments/ra1",
+			[]string{"/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1"},
+		},
+	}
+	for _, tt := range tests {
+		id, err := Parse(tt.in)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.in, err)
+		}
+		var got []string
+		for _, a := range id.Ancestors() {
+			got = append(got, a.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Ancestors(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	// Deriving the parent must not change the child.
+	db, _ := Parse("/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Sql/servers/sql1/databases/orders")
+	p, _ := db.Parent()
+	p.Names[0] = "changed"
+	p.Types = append(p.Types, "elasticPools")
+	if db.Names[0] != "sql1" || db.Types[1] != "databases" {
+		t.Fatalf("Parent shares state with the child: %+v", db)
+	}
+}
+
+func TestParentID(t *testing.T) {
+	if got := ParentID("/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Sql/servers/sql1/databases/orders"); got != "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Sql/servers/sql1" {
+		t.Fatalf("ParentID() = %q", got)
+	}
+	if got := ParentID("not an id"); got != "" {
+		t.Fatalf("ParentID() = %q, want empty", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	got, err := Normalize("  /SUBSCRIPTIONS/sub1/resourcegroups/rg1/providers/Microsoft.KeyVault/vaults/kv1/\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1"; got != want {
+		t.Fatalf("Normalize() = %q, want %q", got, want)
+	}
+	if _, err := Normalize("/subscriptions/sub1/resourceGroups"); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestShortType(t *testing.T) {
+	for in, want := range map[string]string{
+		"Microsoft.App/containerApps":     "containerApps",
+		"Microsoft.Sql/servers/databases": "databases",
+		"custom":                          "custom",
+		"trailing/":                       "trailing/",
+	} {
+		if got := ShortType(in); got != want {
+			t.Errorf("ShortType(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
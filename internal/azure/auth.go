@@ -1,7 +1,10 @@
 package azure
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
@@ -10,11 +13,11 @@ import (
 func GetCredential() (azcore.TokenCredential, error) {
 	cred, err := azidentity.NewDefaultAzureCredential(nil)
 	if err == nil {
-		fmt.Println("Authenticated using cached or default credentials.")
+		_, _ = fmt.Fprintln(os.Stderr, "Authenticated using cached or default credentials.")
 		return cred, nil
 	}
 
-	fmt.Println("Default credentials not available; opening browser for login...")
+	_, _ = fmt.Fprintln(os.Stderr, "Default credentials not available; opening browser for login...")
 	interactive, ierr := azidentity.NewInteractiveBrowserCredential(nil)
 	if ierr != nil {
 		return nil, fmt.Errorf("failed to get Azure credentials: %w", ierr)
@@ -22,3 +25,26 @@ func GetCredential() (azcore.TokenCredential, error) {
 
 	return interactive, nil
 }
+
+// ErrNoSilentCredential is returned by GetSilentCredential when neither
+// environment credentials nor the Azure CLI are available.
+var ErrNoSilentCredential = errors.New("no non-interactive Azure credentials")
+
+// GetSilentCredential returns a credential that never prompts or prints:
+// the AZURE_* environment variables if set, then the Azure CLI's login. It is
+// for work that runs in the background, such as preview panes.
+func GetSilentCredential() (azcore.TokenCredential, error) {
+	var creds []azcore.TokenCredential
+	if env, err := azidentity.NewEnvironmentCredential(nil); err == nil {
+		creds = append(creds, env)
+	}
+	if _, err := exec.LookPath("az"); err == nil {
+		if cli, err := azidentity.NewAzureCLICredential(nil); err == nil {
+			creds = append(creds, cli)
+		}
+	}
+	if len(creds) == 0 {
+		return nil, ErrNoSilentCredential
+	}
+	return azidentity.NewChainedTokenCredential(creds, nil)
+}
@@ -0,0 +1,34 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestListManagementGroups(t *testing.T) {
+	var body struct {
+		Query         string   `json:"query"`
+		Subscriptions []string `json:"subscriptions"`
+	}
+	srv, _ := throttlingServer(t, 0, http.StatusOK, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		writeJSON(w, graphPage([]map[string]any{
+			{"type": "microsoft.management/managementgroups", "name": "platform", "displayName": "Platform", "parent": "root"},
+			{"type": "microsoft.resources/subscriptions", "subscriptionId": "sub1", "parent": "platform"},
+		}, ""))
+	})
+
+	rows, err := ListManagementGroups(context.Background(), fakeCredential{}, testClientOptions(srv, fastRetry(3)))
+	if err != nil {
+		t.Fatalf("list management groups: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["displayName"] != "Platform" || rows[1]["parent"] != "platform" {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+	if len(body.Subscriptions) != 0 || !strings.Contains(body.Query, "ResourceContainers") {
+		t.Fatalf("expected a tenant-scope resource container query, got %+v", body)
+	}
+}
@@ -0,0 +1,50 @@
+package azure
+
+import (
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// RetryConfig tunes how Resource Graph and ARM calls retry transient failures.
+type RetryConfig struct {
+	// MaxRetries is the number of retries after the first attempt. Negative disables retries.
+	MaxRetries int
+	// Delay is the initial backoff; it doubles per retry up to MaxDelay.
+	// A Retry-After header from the service takes precedence.
+	Delay time.Duration
+	// MaxDelay caps the backoff between two attempts.
+	MaxDelay time.Duration
+	// TryTimeout bounds a single attempt. Zero disables the per-try timeout.
+	TryTimeout time.Duration
+	// StatusCodes are the HTTP statuses that are retried. Nil uses the SDK
+	// defaults (408, 429, 500, 502, 503, 504).
+	StatusCodes []int
+}
+
+// DefaultRetryConfig returns the retry settings used when nothing is configured.
+// It retries more patiently than the SDK default because Resource Graph
+// throttles per tenant and a full sync issues one query per subscription.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries: 5,
+		Delay:      2 * time.Second,
+		MaxDelay:   60 * time.Second,
+	}
+}
+
+// ClientOptions converts c into options for the ARM client constructors.
+func (c RetryConfig) ClientOptions() *arm.ClientOptions {
+	return &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Retry: policy.RetryOptions{
+				MaxRetries:    int32(c.MaxRetries),
+				RetryDelay:    c.Delay,
+				MaxRetryDelay: c.MaxDelay,
+				TryTimeout:    c.TryTimeout,
+				StatusCodes:   c.StatusCodes,
+			},
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+)
+
+// Table is a query result with its columns in projection order.
+type Table struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// maxPageSize is the most rows Resource Graph returns per page.
+const maxPageSize = 1000
+
+// Query runs an ad-hoc Resource Graph query over subscriptions, or over every
+// subscription the credential can read if there are none. It follows skip
+// tokens until the result is complete or holds limit rows; a limit of zero
+// means no limit. opts may be nil to use the SDK defaults.
+func Query(ctx context.Context, cred azcore.TokenCredential, query string, subscriptions []string, limit int, opts *arm.ClientOptions) (*Table, error) {
+	client, err := armresourcegraph.NewClient(cred, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource graph client: %w", err)
+	}
+
+	request := armresourcegraph.QueryRequest{
+		Query:         &query,
+		Subscriptions: to.SliceOfPtrs(subscriptions...),
+		Options: &armresourcegraph.QueryRequestOptions{
+			ResultFormat: to.Ptr(armresourcegraph.ResultFormatTable),
+			Top:          to.Ptr(int32(maxPageSize)),
+		},
+	}
+	if limit > 0 && limit < maxPageSize {
+		request.Options.Top = to.Ptr(int32(limit))
+	}
+
+	table := &Table{Rows: [][]any{}}
+	for {
+		resp, err := client.Resources(ctx, request, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute resource graph query: %w", err)
+		}
+		if err := table.add(resp.Data); err != nil {
+			return nil, err
+		}
+
+		if limit > 0 && len(table.Rows) >= limit {
+			table.Rows = table.Rows[:limit]
+			break
+		}
+		if resp.SkipToken == nil || *resp.SkipToken == "" {
+			break
+		}
+		request.Options.SkipToken = resp.SkipToken
+	}
+	return table, nil
+}
+
+// add appends a page in table format: {"columns": [{"name": ...}], "rows": [[...]]}.
+func (t *Table) add(data any) error {
+	page, ok := data.(map[string]any)
+	if !ok {
+		return fmt.Errorf("unexpected data format in resource graph response")
+	}
+
+	if t.Columns == nil {
+		columns, _ := page["columns"].([]any)
+		t.Columns = make([]string, 0, len(columns))
+		for _, c := range columns {
+			col, ok := c.(map[string]any)
+			if !ok {
+				return fmt.Errorf("unexpected column format in resource graph response")
+			}
+			name, _ := col["name"].(string)
+			t.Columns = append(t.Columns, name)
+		}
+	}
+
+	rows, _ := page["rows"].([]any)
+	for _, r := range rows {
+		row, ok := r.([]any)
+		if !ok || len(row) != len(t.Columns) {
+			return fmt.Errorf("unexpected row format in resource graph response")
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	tablePage := func(rows [][]any, skipToken string) map[string]any {
+		page := graphPage(nil, skipToken)
+		page["data"] = map[string]any{
+			"columns": []map[string]any{{"name": "name", "type": "string"}, {"name": "id", "type": "string"}},
+			"rows":    rows,
+		}
+		return page
+	}
+	srv, calls := throttlingServer(t, 0, 0, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Subscriptions []string `json:"subscriptions"`
+			Options       struct {
+				SkipToken    string `json:"$skipToken"`
+				ResultFormat string `json:"resultFormat"`
+			} `json:"options"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Options.ResultFormat != "table" || len(body.Subscriptions) != 1 {
+			t.Errorf("unexpected request: %+v", body)
+		}
+		if body.Options.SkipToken == "" {
+			writeJSON(w, tablePage([][]any{{"a", "/a"}, {"b", "/b"}}, "page2"))
+			return
+		}
+		writeJSON(w, tablePage([][]any{{"c", "/c"}}, ""))
+	})
+	opts := testClientOptions(srv, fastRetry(0))
+
+	table, err := Query(context.Background(), fakeCredential{}, "Resources | project name, id", []string{"sub1"}, 0, opts)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if strings.Join(table.Columns, ",") != "name,id" || len(table.Rows) != 3 || table.Rows[2][0] != "c" {
+		t.Fatalf("expected both pages in projection order, got %+v", table)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected 2 pages, got %d", got)
+	}
+
+	table, err = Query(context.Background(), fakeCredential{}, "Resources | project name, id", []string{"sub1"}, 2, opts)
+	if err != nil || len(table.Rows) != 2 || calls.Load() != 3 {
+		t.Fatalf("expected the limit to stop after the first page, got %+v, %v", table, err)
+	}
+}
@@ -0,0 +1,42 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetResource(t *testing.T) {
+	var query string
+	srv, _ := throttlingServer(t, 1, http.StatusTooManyRequests, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string `json:"query"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		query = req.Query
+		if strings.Contains(query, "missing") {
+			writeJSON(w, graphPage([]map[string]any{}, ""))
+			return
+		}
+		writeJSON(w, graphPage([]map[string]any{{"id": "/subscriptions/sub1/x", "kind": "StorageV2"}}, ""))
+	})
+	opts := testClientOptions(srv, fastRetry(3))
+
+	row, err := GetResource(context.Background(), fakeCredential{}, "sub1", "/subscriptions/sub1/x", opts)
+	if err != nil {
+		t.Fatalf("get resource: %v", err)
+	}
+	if row["kind"] != "StorageV2" {
+		t.Fatalf("unexpected row: %v", row)
+	}
+	if !strings.Contains(query, "id =~ '/subscriptions/sub1/x'") {
+		t.Fatalf("expected the query to filter by id, got %q", query)
+	}
+
+	if _, err := GetResource(context.Background(), fakeCredential{}, "sub1", "/subscriptions/sub1/missing", opts); !errors.Is(err, ErrResourceNotFound) {
+		t.Fatalf("expected ErrResourceNotFound, got %v", err)
+	}
+}
@@ -2,46 +2,129 @@ package azure
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
 )
 
-func ListResources(ctx context.Context, cred azcore.TokenCredential, subscriptionID string, limit int32) ([]map[string]any, error) {
-	client, err := armresourcegraph.NewClient(cred, nil)
+// ListResources returns up to limit resources of a subscription ordered by
+// ID, following Resource Graph skip tokens until the result set is complete.
+// A result of limit rows may be truncated. opts may be nil to use the SDK
+// defaults.
+func ListResources(ctx context.Context, cred azcore.TokenCredential, subscriptionID string, limit int32, opts *arm.ClientOptions) ([]map[string]any, error) {
+	client, err := armresourcegraph.NewClient(cred, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create resource graph client: %w", err)
 	}
 
-	query := fmt.Sprintf("Resources | project id,name,type,subscriptionId,resourceGroup,location,tenantId | limit %d", limit)
-	request := armresourcegraph.QueryRequest{
+	query := fmt.Sprintf("Resources | project id,name,type,subscriptionId,resourceGroup,location,tenantId,sku,tags | order by id asc | limit %d", limit)
+	return queryAll(ctx, client, armresourcegraph.QueryRequest{
 		Subscriptions: []*string{&subscriptionID},
 		Query:         &query,
+		Options:       &armresourcegraph.QueryRequestOptions{},
+	}, limit)
+}
+
+// managementGroupsQuery lists management groups with their parent, and
+// subscriptions with the management group they are directly in.
+const managementGroupsQuery = `ResourceContainers
+| where type =~ 'microsoft.management/managementgroups' or type =~ 'microsoft.resources/subscriptions'
+| extend parent = iff(type =~ 'microsoft.management/managementgroups',
+	tostring(properties.details.parent.name),
+	tostring(properties.managementGroupAncestorsChain[0].name))
+| project type, name, subscriptionId, displayName = tostring(properties.displayName), parent`
+
+// ListManagementGroups returns the management groups and subscriptions
+// visible at tenant scope as rows with type, name, subscriptionId,
+// displayName and parent, the name of the parent management group. opts may
+// be nil to use the SDK defaults.
+func ListManagementGroups(ctx context.Context, cred azcore.TokenCredential, opts *arm.ClientOptions) ([]map[string]any, error) {
+	client, err := armresourcegraph.NewClient(cred, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource graph client: %w", err)
+	}
+
+	query := managementGroupsQuery
+	return queryAll(ctx, client, armresourcegraph.QueryRequest{
+		Query:   &query,
+		Options: &armresourcegraph.QueryRequestOptions{},
+	}, 0)
+}
+
+// queryAll runs request, following skip tokens until the result set is
+// complete or holds at least limit rows. A limit of zero means no limit.
+func queryAll(ctx context.Context, client *armresourcegraph.Client, request armresourcegraph.QueryRequest, limit int32) ([]map[string]any, error) {
+	var results []map[string]any
+	for {
+		resp, err := client.Resources(ctx, request, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute resource graph query: %w", err)
+		}
+
+		if resp.Data == nil {
+			return nil, fmt.Errorf("resource graph query returned no data")
+		}
+
+		data, ok := resp.Data.([]any)
+		if !ok {
+			return nil, fmt.Errorf("unexpected data format in resource graph response")
+		}
+
+		for _, item := range data {
+			m, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("unexpected item format in resource graph response data")
+			}
+			results = append(results, m)
+		}
+
+		if resp.SkipToken == nil || *resp.SkipToken == "" || (limit > 0 && int32(len(results)) >= limit) {
+			break
+		}
+		request.Options.SkipToken = resp.SkipToken
 	}
 
-	resp, err := client.Resources(ctx, request, nil)
+	if results == nil {
+		results = []map[string]any{}
+	}
+	return results, nil
+}
+
+// ErrResourceNotFound is returned by GetResource when Resource Graph does not
+// know the resource, e.g. because it was deleted since the last sync.
+var ErrResourceNotFound = errors.New("resource not found")
+
+// GetResource fetches the current Resource Graph row of a resource, including
+// its kind, sku, tags and properties. opts may be nil to use the SDK defaults.
+func GetResource(ctx context.Context, cred azcore.TokenCredential, subscriptionID, id string, opts *arm.ClientOptions) (map[string]any, error) {
+	client, err := armresourcegraph.NewClient(cred, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute resource graph query: %w", err)
+		return nil, fmt.Errorf("failed to create resource graph client: %w", err)
 	}
 
-	if resp.Data == nil {
-		return nil, fmt.Errorf("resource graph query returned no data")
+	query := fmt.Sprintf("Resources | where id =~ '%s' | limit 1", strings.ReplaceAll(id, "'", `\'`))
+	resp, err := client.Resources(ctx, armresourcegraph.QueryRequest{
+		Subscriptions: []*string{&subscriptionID},
+		Query:         &query,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute resource graph query: %w", err)
 	}
 
 	data, ok := resp.Data.([]any)
 	if !ok {
 		return nil, fmt.Errorf("unexpected data format in resource graph response")
 	}
-
-	results := make([]map[string]any, 0, len(data))
-	for _, item := range data {
-		m, ok := item.(map[string]any)
-		if !ok {
-			return nil, fmt.Errorf("unexpected item format in resource graph response data")
-		}
-		results = append(results, m)
+	if len(data) == 0 {
+		return nil, ErrResourceNotFound
 	}
-
-	return results, nil
+	row, ok := data[0].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected item format in resource graph response data")
+	}
+	return row, nil
 }
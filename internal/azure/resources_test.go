@@ -19,7 +19,7 @@ func TestListResources_Basic(t *testing.T) {
 		t.Skipf("GetCredential failed (likely missing Azure login): %v", err)
 	}
 
-	subs, err := ListSubscriptions(ctx, cred)
+	subs, err := ListSubscriptions(ctx, cred, nil)
 	if err != nil {
 		t.Skipf("ListSubscriptions failed: %v", err)
 	}
@@ -29,7 +29,7 @@ func TestListResources_Basic(t *testing.T) {
 	}
 
 	subID := *subs[0].SubscriptionID
-	res, err := ListResources(ctx, cred, subID, 100)
+	res, err := ListResources(ctx, cred, subID, 100, nil)
 	if err != nil {
 		t.Logf("ListResources failed for %s: %v", subID, err)
 		return
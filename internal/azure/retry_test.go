@@ -0,0 +1,170 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "fake", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// testClientOptions points ARM clients at srv using cfg for retries.
+func testClientOptions(srv *httptest.Server, cfg RetryConfig) *arm.ClientOptions {
+	opts := cfg.ClientOptions()
+	opts.Cloud = cloud.Configuration{
+		ActiveDirectoryAuthorityHost: srv.URL,
+		Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+			cloud.ResourceManager: {Audience: "https://management.core.windows.net/", Endpoint: srv.URL},
+		},
+	}
+	opts.Transport = srv.Client()
+	return opts
+}
+
+func fastRetry(maxRetries int) RetryConfig {
+	return RetryConfig{MaxRetries: maxRetries, Delay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+}
+
+// throttlingServer fails the first failures requests with status and then
+// answers with ok.
+func throttlingServer(t *testing.T, failures int32, status int, ok func(w http.ResponseWriter, r *http.Request)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"error":{"code":"RateLimiting","message":"throttled"}}`))
+			return
+		}
+		ok(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func graphPage(rows []map[string]any, skipToken string) map[string]any {
+	page := map[string]any{
+		"totalRecords":    len(rows),
+		"count":           len(rows),
+		"resultTruncated": "false",
+		"data":            rows,
+	}
+	if skipToken != "" {
+		page["$skipToken"] = skipToken
+	}
+	return page
+}
+
+func TestListResources_RetriesThrottling(t *testing.T) {
+	srv, calls := throttlingServer(t, 2, http.StatusTooManyRequests, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, graphPage([]map[string]any{{"id": "/subscriptions/sub1/resourceGroups/rg/providers/x/y/a", "name": "a"}}, ""))
+	})
+
+	res, err := ListResources(context.Background(), fakeCredential{}, "sub1", 100, testClientOptions(srv, fastRetry(3)))
+	if err != nil {
+		t.Fatalf("expected throttled request to succeed after retries: %v", err)
+	}
+	if len(res) != 1 || res[0]["name"] != "a" {
+		t.Fatalf("unexpected resources: %v", res)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestListResources_GivesUpAfterMaxRetries(t *testing.T) {
+	srv, calls := throttlingServer(t, 100, http.StatusTooManyRequests, func(w http.ResponseWriter, r *http.Request) {
+		// t.Fatal must not be called from the server's goroutine.
+		t.Error("server should never answer successfully")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	_, err := ListResources(context.Background(), fakeCredential{}, "sub1", 100, testClientOptions(srv, fastRetry(2)))
+	if err == nil {
+		t.Fatal("expected error once retries are exhausted")
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("expected 1 attempt + 2 retries, got %d", got)
+	}
+}
+
+func TestListResources_CustomStatusCodes(t *testing.T) {
+	srv, calls := throttlingServer(t, 1, http.StatusInternalServerError, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, graphPage(nil, ""))
+	})
+
+	cfg := fastRetry(3)
+	cfg.StatusCodes = []int{http.StatusTooManyRequests}
+	if _, err := ListResources(context.Background(), fakeCredential{}, "sub1", 100, testClientOptions(srv, cfg)); err == nil {
+		t.Fatal("expected 500 to fail when only 429 is retried")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected a single attempt, got %d", got)
+	}
+}
+
+func TestListResources_FollowsSkipToken(t *testing.T) {
+	srv, calls := throttlingServer(t, 0, 0, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Options struct {
+				SkipToken string `json:"$skipToken"`
+			} `json:"options"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Options.SkipToken == "" {
+			writeJSON(w, graphPage([]map[string]any{{"name": "first"}}, "page2"))
+			return
+		}
+		writeJSON(w, graphPage([]map[string]any{{"name": "second"}}, ""))
+	})
+
+	res, err := ListResources(context.Background(), fakeCredential{}, "sub1", 100, testClientOptions(srv, fastRetry(0)))
+	if err != nil {
+		t.Fatalf("list resources: %v", err)
+	}
+	if len(res) != 2 || res[1]["name"] != "second" {
+		t.Fatalf("expected both pages, got %v", res)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected 2 requests, got %d", got)
+	}
+}
+
+func TestListSubscriptions_RetriesServiceUnavailable(t *testing.T) {
+	srv, calls := throttlingServer(t, 1, http.StatusServiceUnavailable, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/subscriptions") {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		writeJSON(w, map[string]any{"value": []map[string]any{{"subscriptionId": "sub1", "displayName": "prod"}}})
+	})
+
+	subs, err := ListSubscriptions(context.Background(), fakeCredential{}, testClientOptions(srv, fastRetry(3)))
+	if err != nil {
+		t.Fatalf("list subscriptions: %v", err)
+	}
+	if len(subs) != 1 || *subs[0].DisplayName != "prod" {
+		t.Fatalf("unexpected subscriptions: %v", subs)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected 2 attempts, got %d", got)
+	}
+}
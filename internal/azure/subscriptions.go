@@ -5,12 +5,14 @@ import (
 	"fmt"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
 )
 
 // ListSubscriptions retrieves all accessible subscriptions using the provided credential.
-func ListSubscriptions(ctx context.Context, cred azcore.TokenCredential) ([]*armsubscriptions.Subscription, error) {
-	client, err := armsubscriptions.NewClient(cred, nil)
+// opts may be nil to use the SDK defaults.
+func ListSubscriptions(ctx context.Context, cred azcore.TokenCredential, opts *arm.ClientOptions) ([]*armsubscriptions.Subscription, error) {
+	client, err := armsubscriptions.NewClient(cred, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create subscriptions client: %w", err)
 	}
@@ -28,3 +30,25 @@ func ListSubscriptions(ctx context.Context, cred azcore.TokenCredential) ([]*arm
 
 	return subs, nil
 }
+
+// ListTenants retrieves the tenants the credential has access to, with their
+// display names and default domains. opts may be nil to use the SDK defaults.
+func ListTenants(ctx context.Context, cred azcore.TokenCredential, opts *arm.ClientOptions) ([]*armsubscriptions.TenantIDDescription, error) {
+	client, err := armsubscriptions.NewTenantsClient(cred, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tenants client: %w", err)
+	}
+
+	pager := client.NewListPager(nil)
+	var tenants []*armsubscriptions.TenantIDDescription
+
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get next tenant page: %w", err)
+		}
+		tenants = append(tenants, page.Value...)
+	}
+
+	return tenants, nil
+}
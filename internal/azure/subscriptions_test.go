@@ -19,7 +19,7 @@ func TestListSubscriptions_Basic(t *testing.T) {
 		t.Skipf("GetCredential failed (likely missing Azure login): %v", err)
 	}
 
-	subs, err := ListSubscriptions(ctx, cred)
+	subs, err := ListSubscriptions(ctx, cred, nil)
 	if err != nil {
 		t.Skipf("ListSubscriptions returned error: %v", err)
 	}
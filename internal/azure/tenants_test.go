@@ -0,0 +1,26 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestListTenants(t *testing.T) {
+	srv, _ := throttlingServer(t, 1, http.StatusTooManyRequests, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tenants" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		writeJSON(w, map[string]any{"value": []map[string]any{{
+			"tenantId": "t1", "displayName": "Contoso", "defaultDomain": "contoso.onmicrosoft.com",
+		}}})
+	})
+
+	tenants, err := ListTenants(context.Background(), fakeCredential{}, testClientOptions(srv, fastRetry(3)))
+	if err != nil {
+		t.Fatalf("list tenants: %v", err)
+	}
+	if len(tenants) != 1 || *tenants[0].DisplayName != "Contoso" || *tenants[0].DefaultDomain != "contoso.onmicrosoft.com" {
+		t.Fatalf("unexpected tenants: %v", tenants)
+	}
+}
@@ -0,0 +1,134 @@
+package browser
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Config is the "browser:" section of the config file.
+type Config struct {
+	// Command is a command template such as "firefox -P work {url}". When it
+	// has no {url} placeholder the URL is appended.
+	Command string `mapstructure:"command"`
+	// Tenants override Command for specific tenants.
+	Tenants []TenantConfig `mapstructure:"tenants"`
+	// NoBrowser prints URLs instead of opening them.
+	NoBrowser bool `mapstructure:"no_browser"`
+}
+
+// TenantConfig is a browser command used for one tenant, e.g. to open each
+// tenant in its own browser profile.
+type TenantConfig struct {
+	// Tenant is a tenant ID or domain, compared case-insensitively.
+	Tenant  string `mapstructure:"tenant"`
+	Command string `mapstructure:"command"`
+}
+
+// Launcher opens URLs according to a Config.
+type Launcher struct {
+	cfg Config
+	out io.Writer
+
+	// Hooks replaced in tests.
+	goos   string
+	getenv func(string) string
+	start  func(argv []string) error
+}
+
+// New returns a launcher for cfg that prints to stdout in no-browser mode.
+func New(cfg Config) *Launcher {
+	return &Launcher{
+		cfg:    cfg,
+		out:    os.Stdout,
+		goos:   runtime.GOOS,
+		getenv: os.Getenv,
+		start:  startDetached,
+	}
+}
+
+// Open opens url, choosing the command for the tenant identified by any of
+// tenants, e.g. its ID and its default domain. tenants may be empty.
+func (l *Launcher) Open(url string, tenants ...string) error {
+	if l.cfg.NoBrowser {
+		_, err := fmt.Fprintln(l.out, url)
+		return err
+	}
+
+	argv, err := l.Command(url, tenants...)
+	if err != nil {
+		return err
+	}
+	if err := l.start(argv); err != nil {
+		return fmt.Errorf("start browser %q: %w", argv[0], err)
+	}
+	return nil
+}
+
+// Command returns the argv that opens url for the tenant identified by any of
+// tenants. The first match wins: a tenant override, the configured command,
+// $BROWSER, the platform opener.
+func (l *Launcher) Command(url string, tenants ...string) ([]string, error) {
+	for _, t := range l.cfg.Tenants {
+		if t.Command != "" && slices.ContainsFunc(tenants, func(tenant string) bool {
+			return tenant != "" && strings.EqualFold(t.Tenant, tenant)
+		}) {
+			return expand(t.Command, url, "{url}")
+		}
+	}
+	if l.cfg.Command != "" {
+		return expand(l.cfg.Command, url, "{url}")
+	}
+	if env := l.getenv("BROWSER"); env != "" {
+		// $BROWSER may list several commands separated by ':' and mark the
+		// URL with %s; the first entry is used.
+		first, _, _ := strings.Cut(env, ":")
+		return expand(first, url, "%s")
+	}
+
+	switch l.goos {
+	case "darwin":
+		return []string{"open", url}, nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return []string{"xdg-open", url}, nil
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler", url}, nil
+	default:
+		return nil, fmt.Errorf("no browser command for %s; set browser.command or $BROWSER", l.goos)
+	}
+}
+
+// expand splits tmpl into arguments and substitutes placeholder with url,
+// appending url when the placeholder is absent.
+func expand(tmpl, url, placeholder string) ([]string, error) {
+	fields := strings.Fields(tmpl)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty browser command")
+	}
+
+	found := false
+	argv := make([]string, len(fields))
+	for i, f := range fields {
+		if strings.Contains(f, placeholder) {
+			found = true
+			f = strings.ReplaceAll(f, placeholder, url)
+		}
+		argv[i] = f
+	}
+	if !found {
+		argv = append(argv, url)
+	}
+	return argv, nil
+}
+
+func startDetached(argv []string) error {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
@@ -0,0 +1,103 @@
+package browser
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const url = "https://portal.azure.com/#@tenant1/resource/subscriptions/sub1"
+
+// fakeLauncher returns a launcher that records the argv it would start.
+func fakeLauncher(cfg Config, goos string, env map[string]string) (*Launcher, *[]string) {
+	var started []string
+	l := New(cfg)
+	l.goos = goos
+	l.getenv = func(k string) string { return env[k] }
+	l.start = func(argv []string) error {
+		started = argv
+		return nil
+	}
+	return l, &started
+}
+
+func TestOpenCommand(t *testing.T) {
+	cfg := Config{
+		Command: "firefox -P work {url}",
+		Tenants: []TenantConfig{
+			{Tenant: "contoso.onmicrosoft.com", Command: "firefox -P contoso --private-window {url}"},
+			{Tenant: "tenant-guid", Command: "chromium --profile-directory=Fabrikam"},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		cfg    Config
+		tenant string
+		goos   string
+		env    map[string]string
+		want   []string
+	}{
+		{"template", cfg, "other", "linux", nil, []string{"firefox", "-P", "work", url}},
+		{"tenant override by domain", cfg, "Contoso.onmicrosoft.com", "linux", nil, []string{"firefox", "-P", "contoso", "--private-window", url}},
+		{"tenant override appends url", cfg, "tenant-guid", "linux", nil, []string{"chromium", "--profile-directory=Fabrikam", url}},
+		{"$BROWSER with placeholder", Config{}, "", "linux", map[string]string{"BROWSER": "w3m %s:lynx"}, []string{"w3m", url}},
+		{"$BROWSER without placeholder", Config{}, "", "linux", map[string]string{"BROWSER": "brave"}, []string{"brave", url}},
+		{"config beats $BROWSER", Config{Command: "safari"}, "", "darwin", map[string]string{"BROWSER": "brave"}, []string{"safari", url}},
+		{"darwin default", Config{}, "", "darwin", nil, []string{"open", url}},
+		{"linux default", Config{}, "", "linux", nil, []string{"xdg-open", url}},
+		{"windows default", Config{}, "", "windows", nil, []string{"rundll32", "url.dll,FileProtocolHandler", url}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, started := fakeLauncher(tc.cfg, tc.goos, tc.env)
+			if err := l.Open(url, tc.tenant); err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			if !reflect.DeepEqual(*started, tc.want) {
+				t.Fatalf("expected %q, got %q", tc.want, *started)
+			}
+		})
+	}
+}
+
+func TestOpenMatchesAnyTenantIdentifier(t *testing.T) {
+	cfg := Config{Tenants: []TenantConfig{{Tenant: "contoso.onmicrosoft.com", Command: "firefox -P contoso"}}}
+	l, started := fakeLauncher(cfg, "linux", nil)
+	if err := l.Open(url, "tenant-guid", "contoso.onmicrosoft.com"); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if want := []string{"firefox", "-P", "contoso", url}; !reflect.DeepEqual(*started, want) {
+		t.Fatalf("expected %q, got %q", want, *started)
+	}
+}
+
+func TestOpenNoBrowserPrintsURL(t *testing.T) {
+	l, started := fakeLauncher(Config{Command: "firefox {url}", NoBrowser: true}, "linux", nil)
+	var buf bytes.Buffer
+	l.out = &buf
+
+	if err := l.Open(url, ""); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if *started != nil {
+		t.Fatalf("expected no command to run, got %q", *started)
+	}
+	if got := buf.String(); got != url+"\n" {
+		t.Fatalf("expected URL to be printed, got %q", got)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	l, _ := fakeLauncher(Config{}, "plan9", nil)
+	if err := l.Open(url, ""); err == nil {
+		t.Fatal("expected error for platform without opener")
+	}
+
+	l, _ = fakeLauncher(Config{Command: "missing-browser"}, "linux", nil)
+	l.start = func([]string) error { return errors.New("not found") }
+	if err := l.Open(url, ""); err == nil {
+		t.Fatal("expected start error to be returned")
+	}
+}
@@ -15,23 +15,36 @@ type DB struct {
 	conn *sql.DB
 }
 
-// Open initializes (or creates) the azf cache database.
-func Open(ctx context.Context) (*DB, error) {
+// Dir returns the azf cache directory, honouring XDG_CACHE_HOME.
+func Dir() (string, error) {
 	cacheDir := os.Getenv("XDG_CACHE_HOME")
 	if cacheDir == "" {
 		home, err := os.UserHomeDir()
 		if err != nil {
-			return nil, fmt.Errorf("failed to resolve home dir: %w", err)
+			return "", fmt.Errorf("failed to resolve home dir: %w", err)
 		}
 		cacheDir = filepath.Join(home, ".cache")
 	}
+	return filepath.Join(cacheDir, "azf"), nil
+}
+
+// Open initializes (or creates) the azf cache database.
+func Open(ctx context.Context) (*DB, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
 
-	dbPath := filepath.Join(cacheDir, "azf", "azf.db")
-	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+	dbPath := filepath.Join(dir, "azf.db")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return nil, fmt.Errorf("failed to create cache dir: %w", err)
 	}
 
-	conn, err := sql.Open("sqlite", dbPath)
+	// A background sync writes while searches read and record opens. WAL
+	// lets readers run alongside the writer, busy_timeout makes writers wait
+	// for each other instead of failing with SQLITE_BUSY, and immediate
+	// transactions take the write lock up front so that wait applies.
+	conn, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open cache db: %w", err)
 	}
@@ -54,6 +67,31 @@ func Open(ctx context.Context) (*DB, error) {
 		location TEXT,
 		tenantId TEXT,
 		updatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS sync_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		startedAt TIMESTAMP NOT NULL,
+		finishedAt TIMESTAMP,
+		subscriptions INTEGER NOT NULL DEFAULT 0,
+		failed INTEGER NOT NULL DEFAULT 0,
+		resources INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS subscription_sync (
+		runId INTEGER NOT NULL REFERENCES sync_runs(id) ON DELETE CASCADE,
+		subscriptionId TEXT NOT NULL,
+		displayName TEXT NOT NULL DEFAULT '',
+		startedAt TIMESTAMP NOT NULL,
+		finishedAt TIMESTAMP NOT NULL,
+		durationMs INTEGER NOT NULL DEFAULT 0,
+		resources INTEGER NOT NULL DEFAULT 0,
+		added INTEGER NOT NULL DEFAULT 0,
+		updated INTEGER NOT NULL DEFAULT 0,
+		removed INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (runId, subscriptionId)
 	);`
 
 	if _, err := conn.ExecContext(ctx, schema); err != nil {
@@ -64,6 +102,14 @@ func Open(ctx context.Context) (*DB, error) {
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
+	if err := migrate(ctx, conn); err != nil {
+		closeErr := conn.Close()
+		if closeErr != nil {
+			return nil, fmt.Errorf("failed to migrate schema: %w (also failed to close: %v)", err, closeErr)
+		}
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	return &DB{conn: conn}, nil
 }
 
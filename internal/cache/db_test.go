@@ -2,9 +2,16 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/chege/azfind/internal/armid"
 )
 
 func TestOpenAndCloseDB(t *testing.T) {
@@ -109,3 +116,443 @@ func TestDBCreatesUnderCacheDir(t *testing.T) {
 		t.Fatalf("expected db file at %s: %v", dbPath, err)
 	}
 }
+
+func TestConcurrentHandles(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	// One handle stands in for a background sync, the other for a search
+	// that records what it opened.
+	syncDB, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func() {
+		_ = syncDB.Close()
+	}()
+	searchDB, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func() {
+		_ = searchDB.Close()
+	}()
+
+	var mode string
+	if err := searchDB.conn.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&mode); err != nil {
+		t.Fatalf("failed to read journal mode: %v", err)
+	}
+	if mode != "wal" {
+		t.Fatalf("journal_mode = %q, want wal", mode)
+	}
+
+	resources := make([]Resource, 500)
+	for i := range resources {
+		resources[i] = Resource{
+			ID:             fmt.Sprintf("/subscriptions/sub1/resourceGroups/rg/providers/Microsoft.Web/sites/app-%d", i),
+			Name:           fmt.Sprintf("app-%d", i),
+			Type:           "Microsoft.Web/sites",
+			SubscriptionID: "sub1",
+			ResourceGroup:  "rg",
+		}
+	}
+
+	errs := make(chan error, 2)
+	go func() {
+		for range 20 {
+			if _, err := syncDB.ReplaceSubscriptionResources(ctx, "sub1", resources); err != nil {
+				errs <- fmt.Errorf("sync: %w", err)
+				return
+			}
+		}
+		errs <- nil
+	}()
+	go func() {
+		for range 20 {
+			if err := searchDB.RecordOpens(ctx, "open", time.Now(), resources[0].ID); err != nil {
+				errs <- fmt.Errorf("record opens: %w", err)
+				return
+			}
+			if _, err := searchDB.ListResources(ctx); err != nil {
+				errs <- fmt.Errorf("list: %w", err)
+				return
+			}
+		}
+		errs <- nil
+	}()
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, _, err := syncDB.OpenHistory(ctx, resources[0].ID, 0)
+	if err != nil {
+		t.Fatalf("failed to read open history: %v", err)
+	}
+	if n != 20 {
+		t.Fatalf("expected 20 recorded opens, got %d", n)
+	}
+}
+
+func TestLastUpdated(t *testing.T) {
+	ctx := context.Background()
+	tmp := t.TempDir()
+	_ = os.Setenv("XDG_CACHE_HOME", tmp)
+	defer func() {
+		_ = os.Unsetenv("XDG_CACHE_HOME")
+	}()
+
+	db, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func(db *DB) {
+		_ = db.Close()
+	}(db)
+
+	last, err := db.LastUpdated(ctx)
+	if err != nil {
+		t.Fatalf("last updated on empty cache: %v", err)
+	}
+	if !last.IsZero() {
+		t.Fatalf("expected zero time for empty cache, got %v", last)
+	}
+
+	if _, err := db.conn.ExecContext(ctx, `
+		INSERT INTO resources (id, name, updatedAt) VALUES
+		('1', 'old', '2024-01-01 10:00:00'),
+		('2', 'new', '2024-03-05 12:30:00');`); err != nil {
+		t.Fatalf("seed resources: %v", err)
+	}
+
+	last, err = db.LastUpdated(ctx)
+	if err != nil {
+		t.Fatalf("last updated: %v", err)
+	}
+	want := time.Date(2024, 3, 5, 12, 30, 0, 0, time.UTC)
+	if !last.Equal(want) {
+		t.Fatalf("expected %v, got %v", want, last)
+	}
+}
+
+func TestReplaceSubscriptionResources(t *testing.T) {
+	ctx := context.Background()
+	tmp := t.TempDir()
+	_ = os.Setenv("XDG_CACHE_HOME", tmp)
+	defer func() {
+		_ = os.Unsetenv("XDG_CACHE_HOME")
+	}()
+
+	db, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func(db *DB) {
+		_ = db.Close()
+	}(db)
+
+	initial := []Resource{
+		{ID: "1", Name: "kv-prod", Type: "Microsoft.KeyVault/vaults", SubscriptionID: "sub1", ResourceGroup: "rg1"},
+		{ID: "2", Name: "vm-old", Type: "Microsoft.Compute/virtualMachines", SubscriptionID: "sub1", ResourceGroup: "rg1"},
+		{ID: "3", Name: "other-sub", Type: "Microsoft.Web/sites", SubscriptionID: "sub2", ResourceGroup: "rg9"},
+	}
+	if err := db.InsertResources(ctx, initial); err != nil {
+		t.Fatalf("failed to insert resources: %v", err)
+	}
+
+	next := []Resource{
+		{ID: "1", Name: "kv-prod", Type: "Microsoft.KeyVault/vaults", SubscriptionID: "sub1", ResourceGroup: "rg2"},
+		{ID: "4", Name: "app-new", Type: "Microsoft.Web/sites", SubscriptionID: "sub1", ResourceGroup: "rg1"},
+	}
+	stats, err := db.ReplaceSubscriptionResources(ctx, "sub1", next)
+	if err != nil {
+		t.Fatalf("replace resources: %v", err)
+	}
+	want := ChangeStats{Added: 1, Updated: 1, Removed: 1}
+	if stats != want {
+		t.Fatalf("expected %+v, got %+v", want, stats)
+	}
+
+	list, err := db.ListResources(ctx)
+	if err != nil {
+		t.Fatalf("failed to list resources: %v", err)
+	}
+	var names []string
+	for _, r := range list {
+		names = append(names, r.Name)
+	}
+	if got := strings.Join(names, ","); got != "app-new,kv-prod,other-sub" {
+		t.Fatalf("unexpected cache contents: %s", got)
+	}
+
+	stats, err = db.ReplaceSubscriptionResources(ctx, "sub1", next)
+	if err != nil {
+		t.Fatalf("replace resources again: %v", err)
+	}
+	if stats != (ChangeStats{}) {
+		t.Fatalf("expected no changes on identical sync, got %+v", stats)
+	}
+
+	// Resource Graph may report an ID in a different case; it is the same
+	// resource, not a new one plus a removed one.
+	const kv = "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv-prod"
+	if _, err := db.ReplaceSubscriptionResources(ctx, "sub1", []Resource{{ID: kv, Name: "kv-prod", SubscriptionID: "sub1"}}); err != nil {
+		t.Fatalf("replace resources: %v", err)
+	}
+	stats, err = db.ReplaceSubscriptionResources(ctx, "sub1", []Resource{{ID: strings.ToLower(kv), Name: "kv-prod", SubscriptionID: "sub1"}})
+	if err != nil {
+		t.Fatalf("replace resources with a recased id: %v", err)
+	}
+	if want := (ChangeStats{Updated: 1}); stats != want {
+		t.Fatalf("expected %+v for a recased id, got %+v", want, stats)
+	}
+	if r, err := db.FindResourceByID(ctx, kv); err != nil || r == nil || r.ID != strings.ToLower(kv) {
+		t.Fatalf("expected the recased row, got %+v, %v", r, err)
+	}
+}
+
+func TestResourceDetailsAndLookups(t *testing.T) {
+	ctx := context.Background()
+	tmp := t.TempDir()
+	_ = os.Setenv("XDG_CACHE_HOME", tmp)
+	defer func() {
+		_ = os.Unsetenv("XDG_CACHE_HOME")
+	}()
+
+	db, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func(db *DB) {
+		_ = db.Close()
+	}(db)
+
+	resources := []Resource{
+		{ID: "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv-prod", Name: "kv-prod", Type: "Microsoft.KeyVault/vaults",
+			SubscriptionID: "sub1", ResourceGroup: "rg1", SKU: "standard", Tags: map[string]string{"env": "prod"}},
+		{ID: "2", Name: "app", Type: "Microsoft.Web/sites", SubscriptionID: "sub1", ResourceGroup: "RG1"},
+		{ID: "3", Name: "other", Type: "Microsoft.Web/sites", SubscriptionID: "sub1", ResourceGroup: "rg2"},
+	}
+	if err := db.InsertResources(ctx, resources); err != nil {
+		t.Fatalf("failed to insert resources: %v", err)
+	}
+
+	r, err := db.FindResourceByID(ctx, "/SUBSCRIPTIONS/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/KV-PROD")
+	if err != nil {
+		t.Fatalf("find by id: %v", err)
+	}
+	if r == nil || r.SKU != "standard" || r.Tags["env"] != "prod" {
+		t.Fatalf("expected kv-prod with sku and tags, got %+v", r)
+	}
+	if r, err := db.FindResourceByID(ctx, "missing"); err != nil || r != nil {
+		t.Fatalf("expected no resource for unknown id, got %+v, %v", r, err)
+	}
+
+	group, err := db.FindResourcesInGroup(ctx, "sub1", "rg1", 10)
+	if err != nil {
+		t.Fatalf("find in group: %v", err)
+	}
+	if len(group) != 2 || group[0].Name != "kv-prod" || group[1].Name != "app" {
+		t.Fatalf("expected kv-prod and app in rg1, got %+v", group)
+	}
+
+	tagged := resources[:1]
+	tagged[0].Tags = map[string]string{"env": "test"}
+	stats, err := db.ReplaceSubscriptionResources(ctx, "sub1", append(tagged, resources[1:]...))
+	if err != nil {
+		t.Fatalf("replace resources: %v", err)
+	}
+	if stats.Updated != 1 {
+		t.Fatalf("expected a tag change to count as an update, got %+v", stats)
+	}
+}
+
+func TestFindChildren(t *testing.T) {
+	ctx := context.Background()
+	tmp := t.TempDir()
+	_ = os.Setenv("XDG_CACHE_HOME", tmp)
+	defer func() {
+		_ = os.Unsetenv("XDG_CACHE_HOME")
+	}()
+
+	db, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func(db *DB) {
+		_ = db.Close()
+	}(db)
+
+	const server = "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Sql/servers/sql1"
+	const vnet = "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1"
+	resources := []Resource{
+		{ID: server, Name: "sql1", Type: "Microsoft.Sql/servers", SubscriptionID: "sub1"},
+		{ID: server + "/databases/orders", Name: "orders", Type: "Microsoft.Sql/servers/databases", SubscriptionID: "sub1"},
+		{ID: server + "/databases/billing", Name: "billing", Type: "Microsoft.Sql/servers/databases", SubscriptionID: "sub1"},
+		{ID: vnet, Name: "vnet1", Type: "Microsoft.Network/virtualNetworks", SubscriptionID: "sub1"},
+		{ID: vnet + "/subnets/default", Name: "default", Type: "Microsoft.Network/virtualNetworks/subnets", SubscriptionID: "sub1"},
+	}
+	if err := db.InsertResources(ctx, resources); err != nil {
+		t.Fatalf("failed to insert resources: %v", err)
+	}
+
+	r, err := db.FindResourceByID(ctx, server+"/databases/orders")
+	if err != nil || r == nil || r.ParentID != server {
+		t.Fatalf("expected the server as parent, got %+v, %v", r, err)
+	}
+
+	children, err := db.FindChildren(ctx, strings.ToUpper(server))
+	if err != nil {
+		t.Fatalf("find children: %v", err)
+	}
+	if len(children) != 2 || children[0].Name != "billing" || children[1].Name != "orders" {
+		t.Fatalf("expected both databases, got %+v", children)
+	}
+
+	children, err = db.FindChildren(ctx, server, vnet)
+	if err != nil || len(children) != 3 {
+		t.Fatalf("expected the databases and the subnet, got %+v, %v", children, err)
+	}
+	if children, err := db.FindChildren(ctx); err != nil || children != nil {
+		t.Fatalf("expected nothing without parents, got %+v, %v", children, err)
+	}
+}
+
+func TestLookupID(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	db, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	const kv = "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv-prod"
+	if err := db.InsertResources(ctx, []Resource{{ID: kv, Name: "kv-prod", Type: "Microsoft.KeyVault/vaults", SubscriptionID: "sub1", SKU: "standard"}}); err != nil {
+		t.Fatalf("insert resources: %v", err)
+	}
+	if err := db.ReplaceSubscriptions(ctx, []Subscription{{ID: "sub1", DisplayName: "Production", TenantID: "tenant1"}}); err != nil {
+		t.Fatalf("replace subscriptions: %v", err)
+	}
+	if err := db.ReplaceTenants(ctx, []Tenant{{ID: "tenant1", DefaultDomain: "contoso.onmicrosoft.com"}}); err != nil {
+		t.Fatalf("replace tenants: %v", err)
+	}
+
+	r, err := db.LookupID(ctx, " /SUBSCRIPTIONS/sub1/resourcegroups/rg1/providers/Microsoft.KeyVault/vaults/KV-PROD/ ")
+	if err != nil || r == nil || r.SKU != "standard" {
+		t.Fatalf("expected the cached kv-prod, got %+v, %v", r, err)
+	}
+
+	r, err = db.LookupID(ctx, "/subscriptions/sub1/resourcegroups/rg1/providers/Microsoft.Sql/servers/sql1/databases/orders")
+	if err != nil {
+		t.Fatalf("lookup uncached id: %v", err)
+	}
+	want := Resource{
+		ID:             "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Sql/servers/sql1/databases/orders",
+		Name:           "orders",
+		Type:           "Microsoft.Sql/servers/databases",
+		SubscriptionID: "sub1", SubscriptionName: "Production",
+		ResourceGroup: "rg1",
+		TenantID:      "tenant1", TenantDomain: "contoso.onmicrosoft.com",
+		ParentID: "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Sql/servers/sql1",
+	}
+	if !reflect.DeepEqual(*r, want) {
+		t.Fatalf("LookupID() = %+v, want %+v", *r, want)
+	}
+
+	r, err = db.LookupID(ctx, kv+"/providers/Microsoft.Authorization/roleAssignments/ra1")
+	if err != nil {
+		t.Fatalf("lookup extension id: %v", err)
+	}
+	want = Resource{
+		ID:             kv + "/providers/Microsoft.Authorization/roleAssignments/ra1",
+		Name:           "ra1",
+		Type:           "Microsoft.Authorization/roleAssignments",
+		SubscriptionID: "sub1", SubscriptionName: "Production",
+		ResourceGroup: "rg1",
+		TenantID:      "tenant1", TenantDomain: "contoso.onmicrosoft.com",
+		ParentID: kv,
+	}
+	if !reflect.DeepEqual(*r, want) {
+		t.Fatalf("LookupID() = %+v, want %+v", *r, want)
+	}
+
+	if r, err := db.LookupID(ctx, "/subscriptions/sub2/resourceGroups/rg9"); err != nil || r.Type != "Microsoft.Resources/resourceGroups" || r.TenantID != "" {
+		t.Fatalf("expected a resource group without tenant, got %+v, %v", r, err)
+	}
+	if _, err := db.LookupID(ctx, "/subscriptions/sub1/resourceGroups"); !errors.Is(err, armid.ErrInvalid) {
+		t.Fatalf("expected armid.ErrInvalid, got %v", err)
+	}
+}
+
+func TestFindResourcesByExactName(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	db, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	resources := []Resource{
+		{ID: "1", Name: "myapp", Type: "Microsoft.Web/sites", ResourceGroup: "rg1"},
+		{ID: "2", Name: "myapp", Type: "Microsoft.Web/serverFarms", ResourceGroup: "rg1"},
+		{ID: "3", Name: "MyApp", Type: "Microsoft.Insights/components", ResourceGroup: "rg1"},
+		{ID: "4", Name: "myapp-staging", Type: "Microsoft.Web/sites", ResourceGroup: "rg1"},
+	}
+	if err := db.InsertResources(ctx, resources); err != nil {
+		t.Fatalf("insert resources: %v", err)
+	}
+
+	got, err := db.FindResourcesByExactName(ctx, "MYAPP")
+	if err != nil {
+		t.Fatalf("find by exact name: %v", err)
+	}
+	var types []string
+	for _, r := range got {
+		types = append(types, r.Type)
+	}
+	if want := "Microsoft.Insights/components,Microsoft.Web/serverFarms,Microsoft.Web/sites"; strings.Join(types, ",") != want {
+		t.Fatalf("expected %s, got %s", want, strings.Join(types, ","))
+	}
+}
+
+func TestMergeSubscriptionResources(t *testing.T) {
+	ctx := context.Background()
+	_ = os.Setenv("XDG_CACHE_HOME", t.TempDir())
+	defer func() {
+		_ = os.Unsetenv("XDG_CACHE_HOME")
+	}()
+
+	db, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func(db *DB) {
+		_ = db.Close()
+	}(db)
+
+	if err := db.InsertResources(ctx, []Resource{
+		{ID: "1", Name: "kv-prod", SubscriptionID: "sub1", ResourceGroup: "rg1"},
+		{ID: "2", Name: "vm-unlisted", SubscriptionID: "sub1", ResourceGroup: "rg1"},
+	}); err != nil {
+		t.Fatalf("failed to insert resources: %v", err)
+	}
+
+	stats, err := db.MergeSubscriptionResources(ctx, "sub1", []Resource{
+		{ID: "1", Name: "kv-prod", SubscriptionID: "sub1", ResourceGroup: "rg2"},
+		{ID: "3", Name: "app-new", SubscriptionID: "sub1", ResourceGroup: "rg1"},
+	})
+	if err != nil {
+		t.Fatalf("merge resources: %v", err)
+	}
+	if want := (ChangeStats{Added: 1, Updated: 1}); stats != want {
+		t.Fatalf("expected %+v, got %+v", want, stats)
+	}
+	if r, err := db.FindResourceByID(ctx, "2"); err != nil || r == nil {
+		t.Fatalf("expected the unlisted resource to be kept, got %+v, %v", r, err)
+	}
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+)
+
+// ManagementGroup is a node of the management group tree as last listed by a
+// sync.
+type ManagementGroup struct {
+	// ID is the group name, e.g. "landing-zones".
+	ID          string
+	DisplayName string
+	// ParentID is the parent group, or "" for the tenant root group.
+	ParentID string
+}
+
+// Label returns the display name, or the ID if the name is unknown.
+func (g ManagementGroup) Label() string {
+	if g.DisplayName != "" {
+		return g.DisplayName
+	}
+	return g.ID
+}
+
+// ReplaceManagementGroups makes groups the complete set of cached management
+// groups.
+func (db *DB) ReplaceManagementGroups(ctx context.Context, groups []ManagementGroup) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	rollback := func(err error) error {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM management_groups;`); err != nil {
+		return rollback(fmt.Errorf("clear management groups: %w", err))
+	}
+	for _, g := range groups {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR REPLACE INTO management_groups (id, displayName, parentId)
+			VALUES (?, ?, ?);`,
+			g.ID, g.DisplayName, g.ParentID); err != nil {
+			return rollback(fmt.Errorf("insert management group %q: %w", g.ID, err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListManagementGroups returns the cached management groups ordered by
+// display name.
+func (db *DB) ListManagementGroups(ctx context.Context) ([]ManagementGroup, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT id, displayName, parentId
+		FROM management_groups
+		ORDER BY displayName COLLATE NOCASE ASC, id ASC;`)
+	if err != nil {
+		return nil, fmt.Errorf("query management groups: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var groups []ManagementGroup
+	for rows.Next() {
+		var g ManagementGroup
+		if err := rows.Scan(&g.ID, &g.DisplayName, &g.ParentID); err != nil {
+			return nil, fmt.Errorf("scan management group: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration: %w", err)
+	}
+	return groups, nil
+}
+
+// SubscriptionsInManagementGroup returns the IDs of the subscriptions in the
+// management group with the given ID or display name, including those in its
+// descendant groups. Names are compared case-insensitively.
+func (db *DB) SubscriptionsInManagementGroup(ctx context.Context, group string) ([]string, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		WITH RECURSIVE tree(id) AS (
+			SELECT id FROM management_groups WHERE id = ?1 OR displayName = ?1 COLLATE NOCASE
+			UNION
+			SELECT g.id FROM management_groups g JOIN tree ON g.parentId = tree.id
+		)
+		SELECT s.id FROM subscriptions s JOIN tree ON s.managementGroupId = tree.id
+		ORDER BY s.id;`, group)
+	if err != nil {
+		return nil, fmt.Errorf("query management group subscriptions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan subscription id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestManagementGroups(t *testing.T) {
+	ctx := context.Background()
+	tmp := t.TempDir()
+	_ = os.Setenv("XDG_CACHE_HOME", tmp)
+	defer func() {
+		_ = os.Unsetenv("XDG_CACHE_HOME")
+	}()
+
+	db, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func(db *DB) {
+		_ = db.Close()
+	}(db)
+
+	groups := []ManagementGroup{
+		{ID: "root", DisplayName: "Tenant Root Group"},
+		{ID: "landing-zones", DisplayName: "Landing Zones", ParentID: "root"},
+		{ID: "corp", DisplayName: "Corp", ParentID: "landing-zones"},
+		{ID: "sandbox", DisplayName: "Sandbox", ParentID: "root"},
+	}
+	if err := db.ReplaceManagementGroups(ctx, groups); err != nil {
+		t.Fatalf("replace management groups: %v", err)
+	}
+	if err := db.ReplaceSubscriptions(ctx, []Subscription{
+		{ID: "sub-lz", ManagementGroupID: "landing-zones"},
+		{ID: "sub-corp", ManagementGroupID: "CORP"},
+		{ID: "sub-sandbox", ManagementGroupID: "sandbox"},
+		{ID: "sub-none"},
+	}); err != nil {
+		t.Fatalf("replace subscriptions: %v", err)
+	}
+
+	got, err := db.ListManagementGroups(ctx)
+	if err != nil || len(got) != 4 || got[0].ID != "corp" {
+		t.Fatalf("unexpected management groups: %+v, %v", got, err)
+	}
+
+	tests := []struct {
+		group string
+		want  []string
+	}{
+		{"landing-zones", []string{"sub-corp", "sub-lz"}},
+		{"landing zones", []string{"sub-corp", "sub-lz"}},
+		{"root", []string{"sub-corp", "sub-lz", "sub-sandbox"}},
+		{"missing", nil},
+	}
+	for _, tt := range tests {
+		ids, err := db.SubscriptionsInManagementGroup(ctx, tt.group)
+		if err != nil {
+			t.Fatalf("subscriptions in %s: %v", tt.group, err)
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("subscriptions in %s = %q, want %q", tt.group, ids, tt.want)
+		}
+	}
+
+	if err := db.InsertResources(ctx, []Resource{
+		{ID: "1", Name: "a", SubscriptionID: "sub-lz", ResourceGroup: "rg-b"},
+		{ID: "2", Name: "b", SubscriptionID: "sub-lz", ResourceGroup: "RG-A"},
+		{ID: "3", Name: "c", SubscriptionID: "sub-corp", ResourceGroup: "rg-a"},
+		{ID: "4", Name: "d", SubscriptionID: "sub-lz", ResourceGroup: "rg-a"},
+	}); err != nil {
+		t.Fatalf("insert resources: %v", err)
+	}
+	counts, err := db.ResourceCounts(ctx)
+	if err != nil {
+		t.Fatalf("resource counts: %v", err)
+	}
+	if want := map[string]int{"sub-lz": 3, "sub-corp": 1}; !reflect.DeepEqual(counts, want) {
+		t.Fatalf("resource counts = %v, want %v", counts, want)
+	}
+
+	rgs, err := db.ResourceGroups(ctx, "SUB-LZ")
+	if err != nil {
+		t.Fatalf("resource groups: %v", err)
+	}
+	if len(rgs) != 2 || !strings.EqualFold(rgs[0].Name, "rg-a") || rgs[0].Resources != 2 || rgs[1].Resources != 1 {
+		t.Fatalf("unexpected resource groups: %+v", rgs)
+	}
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order on top of the base schema in Open. The
+// number of applied migrations is stored in PRAGMA user_version, so entries
+// must only ever be appended.
+var migrations = []string{
+	// 1: explicit run status so interrupted runs can be resumed.
+	`ALTER TABLE sync_runs ADD COLUMN status TEXT NOT NULL DEFAULT '';`,
+	// 2-3: SKU and tags (a JSON object) for the preview.
+	`ALTER TABLE resources ADD COLUMN sku TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE resources ADD COLUMN tags TEXT NOT NULL DEFAULT '';`,
+	// 4: history of actions run on resources.
+	`CREATE TABLE IF NOT EXISTS opens (
+		resourceId TEXT NOT NULL,
+		action TEXT NOT NULL,
+		openedAt TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS opens_resource ON opens (resourceId COLLATE NOCASE, openedAt);`,
+	// 5: subscription metadata, joined into resource queries for display names.
+	`CREATE TABLE IF NOT EXISTS subscriptions (
+		id TEXT PRIMARY KEY COLLATE NOCASE,
+		displayName TEXT NOT NULL DEFAULT '',
+		state TEXT NOT NULL DEFAULT '',
+		tenantId TEXT NOT NULL DEFAULT '',
+		tags TEXT NOT NULL DEFAULT '',
+		updatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`,
+	// 6: tenant metadata for display names and portal URLs.
+	`CREATE TABLE IF NOT EXISTS tenants (
+		id TEXT PRIMARY KEY COLLATE NOCASE,
+		displayName TEXT NOT NULL DEFAULT '',
+		defaultDomain TEXT NOT NULL DEFAULT '',
+		updatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`,
+	// 7: the management group tree and where subscriptions hang in it.
+	`CREATE TABLE IF NOT EXISTS management_groups (
+		id TEXT PRIMARY KEY COLLATE NOCASE,
+		displayName TEXT NOT NULL DEFAULT '',
+		parentId TEXT NOT NULL DEFAULT '' COLLATE NOCASE
+	);
+	ALTER TABLE subscriptions ADD COLUMN managementGroupId TEXT NOT NULL DEFAULT '' COLLATE NOCASE;`,
+	// 8: the resource a child resource is nested in, e.g. a SQL server.
+	`ALTER TABLE resources ADD COLUMN parentId TEXT NOT NULL DEFAULT '';
+	CREATE INDEX IF NOT EXISTS resources_parent ON resources (parentId COLLATE NOCASE);`,
+	// 9: ARM IDs are case-insensitive, so rebuild resources with a NOCASE key.
+	// Rows that only differ in the case of their ID keep the newest one.
+	`CREATE TABLE resources_nocase (
+		id TEXT PRIMARY KEY COLLATE NOCASE,
+		name TEXT,
+		type TEXT,
+		subscriptionId TEXT,
+		resourceGroup TEXT,
+		location TEXT,
+		tenantId TEXT,
+		updatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		sku TEXT NOT NULL DEFAULT '',
+		tags TEXT NOT NULL DEFAULT '',
+		parentId TEXT NOT NULL DEFAULT ''
+	);
+	INSERT OR REPLACE INTO resources_nocase
+		(id, name, type, subscriptionId, resourceGroup, location, tenantId, updatedAt, sku, tags, parentId)
+	SELECT id, name, type, subscriptionId, resourceGroup, location, tenantId, updatedAt, sku, tags, parentId
+	FROM resources ORDER BY updatedAt;
+	DROP TABLE resources;
+	ALTER TABLE resources_nocase RENAME TO resources;
+	CREATE INDEX IF NOT EXISTS resources_parent ON resources (parentId COLLATE NOCASE);`,
+}
+
+func migrate(ctx context.Context, conn *sql.DB) error {
+	var version int
+	if err := conn.QueryRowContext(ctx, `PRAGMA user_version;`).Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("begin migration %d: %w", i+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("apply migration %d: %w", i+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`PRAGMA user_version = %d;`, i+1)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("record migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
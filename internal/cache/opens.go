@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// OpenRecord records an action run on a resource from the picker or by name.
+type OpenRecord struct {
+	ResourceID string
+	Action     string
+	At         time.Time
+}
+
+// RecordOpens records that action ran on each of the given resources at t.
+func (db *DB) RecordOpens(ctx context.Context, action string, at time.Time, resourceIDs ...string) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	for _, id := range resourceIDs {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO opens (resourceId, action, openedAt) VALUES (?, ?, ?);`,
+			id, action, formatTimestamp(at)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("record open of %q: %w", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// OpenHistory returns how often actions ran on a resource and the latest
+// limit of them, newest first.
+func (db *DB) OpenHistory(ctx context.Context, resourceID string, limit int) (int, []OpenRecord, error) {
+	var count int
+	if err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM opens WHERE resourceId = ? COLLATE NOCASE;`, resourceID).Scan(&count); err != nil {
+		return 0, nil, fmt.Errorf("count opens: %w", err)
+	}
+
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT resourceId, action, openedAt
+		FROM opens
+		WHERE resourceId = ? COLLATE NOCASE
+		ORDER BY openedAt DESC
+		LIMIT ?;`, resourceID, limit)
+	if err != nil {
+		return 0, nil, fmt.Errorf("query opens: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var opens []OpenRecord
+	for rows.Next() {
+		var o OpenRecord
+		var at sql.NullString
+		if err := rows.Scan(&o.ResourceID, &o.Action, &at); err != nil {
+			return 0, nil, fmt.Errorf("scan open: %w", err)
+		}
+		if o.At, err = parseTimestamp(at); err != nil {
+			return 0, nil, err
+		}
+		opens = append(opens, o)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, fmt.Errorf("iteration: %w", err)
+	}
+	return count, opens, nil
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestOpenHistory(t *testing.T) {
+	ctx := context.Background()
+	tmp := t.TempDir()
+	_ = os.Setenv("XDG_CACHE_HOME", tmp)
+	defer func() {
+		_ = os.Unsetenv("XDG_CACHE_HOME")
+	}()
+
+	db, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func(db *DB) {
+		_ = db.Close()
+	}(db)
+
+	first := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	if err := db.RecordOpens(ctx, "open", first, "/subscriptions/sub1/r1", "/subscriptions/sub1/r2"); err != nil {
+		t.Fatalf("record opens: %v", err)
+	}
+	if err := db.RecordOpens(ctx, "copy-id", first.Add(time.Hour), "/subscriptions/sub1/r1"); err != nil {
+		t.Fatalf("record opens: %v", err)
+	}
+
+	count, opens, err := db.OpenHistory(ctx, "/SUBSCRIPTIONS/sub1/r1", 1)
+	if err != nil {
+		t.Fatalf("open history: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 opens, got %d", count)
+	}
+	if len(opens) != 1 || opens[0].Action != "copy-id" || !opens[0].At.Equal(first.Add(time.Hour)) {
+		t.Fatalf("expected the latest open only, got %+v", opens)
+	}
+}
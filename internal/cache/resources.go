@@ -3,9 +3,14 @@ package cache
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"strings"
 	"time"
+
+	"github.com/chege/azfind/internal/armid"
 )
 
 // Resource represents a cached Azure resource.
@@ -17,7 +22,78 @@ type Resource struct {
 	ResourceGroup  string
 	Location       string
 	TenantID       string
-	UpdatedAt      time.Time
+	// SubscriptionName is the display name of the subscription, if known.
+	SubscriptionName string
+	// TenantName and TenantDomain are the display name and default domain of
+	// the tenant, if known.
+	TenantName   string
+	TenantDomain string
+	// ParentID is the resource this one is nested in, e.g. the SQL server
+	// of a database. It is derived from ID when the resource is stored.
+	ParentID  string
+	SKU       string
+	Tags      map[string]string
+	UpdatedAt time.Time
+}
+
+// SubscriptionLabel returns the subscription display name, or its ID if the
+// name is unknown.
+func (r Resource) SubscriptionLabel() string {
+	if r.SubscriptionName != "" {
+		return r.SubscriptionName
+	}
+	return r.SubscriptionID
+}
+
+// TenantLabel returns the tenant display name, its default domain or its ID,
+// whichever is known first.
+func (r Resource) TenantLabel() string {
+	switch {
+	case r.TenantName != "":
+		return r.TenantName
+	case r.TenantDomain != "":
+		return r.TenantDomain
+	}
+	return r.TenantID
+}
+
+// PortalTenant returns how the tenant is addressed in portal URLs: its
+// default domain if known, its ID otherwise.
+func (r Resource) PortalTenant() string {
+	if r.TenantDomain != "" {
+		return r.TenantDomain
+	}
+	return r.TenantID
+}
+
+// resourceColumns lists the columns in the order scanResource expects. They
+// are selected from resourceTables.
+const resourceColumns = "r.id, r.name, r.type, r.subscriptionId, r.resourceGroup, r.location, r.tenantId, r.sku, r.tags, r.parentId, r.updatedAt, COALESCE(s.displayName, ''), COALESCE(t.displayName, ''), COALESCE(t.defaultDomain, '')"
+
+// resourceTables joins the resources with their subscription and tenant.
+const resourceTables = "resources r LEFT JOIN subscriptions s ON s.id = r.subscriptionId LEFT JOIN tenants t ON t.id = r.tenantId"
+
+// encodeTags stores tags as a JSON object; no tags are stored as "".
+func encodeTags(tags map[string]string) (string, error) {
+	if len(tags) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(tags)
+	if err != nil {
+		return "", fmt.Errorf("encode tags: %w", err)
+	}
+	return string(b), nil
+}
+
+func decodeTags(raw string) (map[string]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var tags map[string]string
+	if err := json.Unmarshal([]byte(raw), &tags); err != nil {
+		return nil, fmt.Errorf("decode tags: %w", err)
+	}
+	return tags, nil
 }
 
 // InsertResources inserts or replaces multiple resources transactionally.
@@ -33,8 +109,8 @@ func (db *DB) InsertResources(ctx context.Context, resources []Resource) error {
 
 	stmt, err := tx.PrepareContext(ctx, `
 		INSERT OR REPLACE INTO resources
-		(id, name, type, subscriptionId, resourceGroup, location, tenantId, updatedAt)
-		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP);
+		(id, name, type, subscriptionId, resourceGroup, location, tenantId, sku, tags, parentId, updatedAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP);
 	`)
 	if err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
@@ -47,7 +123,14 @@ func (db *DB) InsertResources(ctx context.Context, resources []Resource) error {
 	}()
 
 	for _, r := range resources {
-		if _, err := stmt.ExecContext(ctx, r.ID, r.Name, r.Type, r.SubscriptionID, r.ResourceGroup, r.Location, r.TenantID); err != nil {
+		tags, err := encodeTags(r.Tags)
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return fmt.Errorf("insert resource %q: %w (rollback failed: %v)", r.ID, err, rbErr)
+			}
+			return fmt.Errorf("insert resource %q: %w", r.ID, err)
+		}
+		if _, err := stmt.ExecContext(ctx, r.ID, r.Name, r.Type, r.SubscriptionID, r.ResourceGroup, r.Location, r.TenantID, r.SKU, tags, armid.ParentID(r.ID)); err != nil {
 			if rbErr := tx.Rollback(); rbErr != nil {
 				return fmt.Errorf("insert resource %q: %w (rollback failed: %v)", r.ID, err, rbErr)
 			}
@@ -64,8 +147,8 @@ func (db *DB) InsertResources(ctx context.Context, resources []Resource) error {
 // ListResources returns all resources ordered by name, resourceGroup, and type (all COLLATE NOCASE ASC).
 func (db *DB) ListResources(ctx context.Context) ([]Resource, error) {
 	query := `
-		SELECT id, name, type, subscriptionId, resourceGroup, location, tenantId, updatedAt
-		FROM resources
+		SELECT ` + resourceColumns + `
+		FROM ` + resourceTables + `
 		ORDER BY name COLLATE NOCASE ASC,
 		         resourceGroup COLLATE NOCASE ASC,
 		         type COLLATE NOCASE ASC;`
@@ -84,9 +167,9 @@ func (db *DB) ListResources(ctx context.Context) ([]Resource, error) {
 func (db *DB) FindResources(ctx context.Context, query string) ([]Resource, error) {
 	pattern := "%" + query + "%"
 	baseQuery := `
-		SELECT id, name, type, subscriptionId, resourceGroup, location, tenantId, updatedAt
-		FROM resources
-		WHERE name LIKE ? OR id LIKE ?
+		SELECT ` + resourceColumns + `
+		FROM ` + resourceTables + `
+		WHERE name LIKE ? OR r.id LIKE ?
 		ORDER BY name COLLATE NOCASE ASC,
 		         resourceGroup COLLATE NOCASE ASC,
 		         type COLLATE NOCASE ASC;`
@@ -104,8 +187,8 @@ func (db *DB) FindResources(ctx context.Context, query string) ([]Resource, erro
 func (db *DB) FindResourcesByNamePrefix(ctx context.Context, name string) ([]Resource, error) {
 	pattern := name + "%"
 	query := `
-        SELECT id, name, type, subscriptionId, resourceGroup, location, tenantId, updatedAt
-        FROM resources
+        SELECT ` + resourceColumns + `
+        FROM ` + resourceTables + `
         WHERE name LIKE ?
         ORDER BY name COLLATE NOCASE ASC,
                  resourceGroup COLLATE NOCASE ASC,
@@ -120,16 +203,156 @@ func (db *DB) FindResourcesByNamePrefix(ctx context.Context, name string) ([]Res
 	return scanResources(rows)
 }
 
-func (db *DB) FindResourceByExactName(ctx context.Context, name string) (*Resource, error) {
+// FindResourcesByExactName returns the resources named name, ignoring case,
+// ordered by type and resource group. Related resources often share a name,
+// e.g. an app service and its plan.
+func (db *DB) FindResourcesByExactName(ctx context.Context, name string) ([]Resource, error) {
+	query := `
+		SELECT ` + resourceColumns + `
+		FROM ` + resourceTables + `
+		WHERE LOWER(name) = LOWER(?)
+		ORDER BY type COLLATE NOCASE ASC,
+		         resourceGroup COLLATE NOCASE ASC;`
+	rows, err := db.conn.QueryContext(ctx, query, name)
+	if err != nil {
+		return nil, fmt.Errorf("query by exact name: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanResources(rows)
+}
+
+// FindResourceByID returns the resource with the given ID, ignoring case, or
+// nil if it is not cached.
+func (db *DB) FindResourceByID(ctx context.Context, id string) (*Resource, error) {
 	query := `
-        SELECT id, name, type, subscriptionId, resourceGroup, location, tenantId, updatedAt
-        FROM resources
-        WHERE LOWER(name) = LOWER(?)
-        LIMIT 1;`
+		SELECT ` + resourceColumns + `
+		FROM ` + resourceTables + `
+		WHERE r.id = ?
+		LIMIT 1;`
+
+	return scanOne(db.conn.QueryRowContext(ctx, query, id))
+}
 
-	row := db.conn.QueryRowContext(ctx, query, name)
+// LookupID returns the resource with the ARM ID s. An uncached resource is
+// described from the ID alone, with the tenant of its subscription if that is
+// known, so actions such as opening the portal still work. Errors wrap
+// armid.ErrInvalid if s is not a valid ID.
+func (db *DB) LookupID(ctx context.Context, s string) (*Resource, error) {
+	id, err := armid.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if r, err := db.FindResourceByID(ctx, id.String()); err != nil || r != nil {
+		return r, err
+	}
+
+	// An extension resource, such as a role assignment on a key vault, is
+	// placed by the resource it is attached to.
+	scope := id
+	for scope.SubscriptionID == "" && scope.Scope != nil {
+		scope = *scope.Scope
+	}
+	r := &Resource{
+		ID:             id.String(),
+		Name:           id.Name(),
+		Type:           id.Type(),
+		SubscriptionID: scope.SubscriptionID,
+		ResourceGroup:  scope.ResourceGroup,
+		ParentID:       armid.ParentID(id.String()),
+	}
+	switch {
+	case r.Type != "":
+	case id.ManagementGroup != "":
+		r.Type = "Microsoft.Management/managementGroups"
+	case id.ResourceGroup != "":
+		r.Type = "Microsoft.Resources/resourceGroups"
+	default:
+		r.Type = "Microsoft.Resources/subscriptions"
+	}
+	if r.SubscriptionID == "" {
+		return r, nil
+	}
+
+	err = db.conn.QueryRowContext(ctx, `
+		SELECT s.displayName, s.tenantId, COALESCE(t.displayName, ''), COALESCE(t.defaultDomain, '')
+		FROM subscriptions s LEFT JOIN tenants t ON t.id = s.tenantId
+		WHERE s.id = ?;`, r.SubscriptionID).
+		Scan(&r.SubscriptionName, &r.TenantID, &r.TenantName, &r.TenantDomain)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, fmt.Errorf("query subscription %q: %w", r.SubscriptionID, err)
+	}
+	return r, nil
+}
+
+// FindResourcesInGroup returns up to limit resources of a resource group
+// ordered by type and name. A negative limit returns all of them.
+func (db *DB) FindResourcesInGroup(ctx context.Context, subscriptionID, resourceGroup string, limit int) ([]Resource, error) {
+	query := `
+		SELECT ` + resourceColumns + `
+		FROM ` + resourceTables + `
+		WHERE LOWER(subscriptionId) = LOWER(?) AND LOWER(resourceGroup) = LOWER(?)
+		ORDER BY type COLLATE NOCASE ASC,
+		         name COLLATE NOCASE ASC
+		LIMIT ?;`
+	rows, err := db.conn.QueryContext(ctx, query, subscriptionID, resourceGroup, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query resource group: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanResources(rows)
+}
+
+// FindChildren returns the resources nested directly in any of the resources
+// with the given IDs, ordered by type and name.
+func (db *DB) FindChildren(ctx context.Context, parentIDs ...string) ([]Resource, error) {
+	if len(parentIDs) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(parentIDs))
+	for i, id := range parentIDs {
+		args[i] = id
+	}
+	query := `
+		SELECT ` + resourceColumns + `
+		FROM ` + resourceTables + `
+		WHERE r.parentId COLLATE NOCASE IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(parentIDs)), ", ") + `)
+		ORDER BY r.type COLLATE NOCASE ASC,
+		         r.name COLLATE NOCASE ASC;`
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query children: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanResources(rows)
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanResource(row scanner) (Resource, error) {
 	var r Resource
-	if err := row.Scan(&r.ID, &r.Name, &r.Type, &r.SubscriptionID, &r.ResourceGroup, &r.Location, &r.TenantID, &r.UpdatedAt); err != nil {
+	var tags string
+	if err := row.Scan(&r.ID, &r.Name, &r.Type, &r.SubscriptionID, &r.ResourceGroup, &r.Location, &r.TenantID, &r.SKU, &tags, &r.ParentID, &r.UpdatedAt, &r.SubscriptionName, &r.TenantName, &r.TenantDomain); err != nil {
+		return Resource{}, err
+	}
+	var err error
+	if r.Tags, err = decodeTags(tags); err != nil {
+		return Resource{}, fmt.Errorf("resource %q: %w", r.ID, err)
+	}
+	return r, nil
+}
+
+// scanOne scans a single resource, returning nil if the row does not exist.
+func scanOne(row *sql.Row) (*Resource, error) {
+	r, err := scanResource(row)
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -141,8 +364,8 @@ func (db *DB) FindResourceByExactName(ctx context.Context, name string) (*Resour
 func scanResources(rows *sql.Rows) ([]Resource, error) {
 	var results []Resource
 	for rows.Next() {
-		var r Resource
-		if err := rows.Scan(&r.ID, &r.Name, &r.Type, &r.SubscriptionID, &r.ResourceGroup, &r.Location, &r.TenantID, &r.UpdatedAt); err != nil {
+		r, err := scanResource(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan resource: %w", err)
 		}
 		results = append(results, r)
@@ -152,3 +375,194 @@ func scanResources(rows *sql.Rows) ([]Resource, error) {
 	}
 	return results, nil
 }
+
+// sqliteTimestamp is the layout SQLite uses for CURRENT_TIMESTAMP (always UTC).
+const sqliteTimestamp = "2006-01-02 15:04:05"
+
+// LastUpdated returns the most recent updatedAt across all cached resources.
+// It returns the zero time when the cache is empty.
+func (db *DB) LastUpdated(ctx context.Context) (time.Time, error) {
+	var raw sql.NullString
+	if err := db.conn.QueryRowContext(ctx, `SELECT MAX(updatedAt) FROM resources;`).Scan(&raw); err != nil {
+		return time.Time{}, fmt.Errorf("query last update: %w", err)
+	}
+	if !raw.Valid || raw.String == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.ParseInLocation(sqliteTimestamp, raw.String, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse last update %q: %w", raw.String, err)
+	}
+	return t, nil
+}
+
+// ResourceCounts returns the number of cached resources per subscription ID.
+func (db *DB) ResourceCounts(ctx context.Context) (map[string]int, error) {
+	rows, err := db.conn.QueryContext(ctx, `SELECT subscriptionId, COUNT(*) FROM resources GROUP BY subscriptionId;`)
+	if err != nil {
+		return nil, fmt.Errorf("count resources: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, fmt.Errorf("scan resource count: %w", err)
+		}
+		counts[id] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration: %w", err)
+	}
+	return counts, nil
+}
+
+// ResourceGroup is a resource group known from its cached resources.
+type ResourceGroup struct {
+	Name      string
+	Resources int
+}
+
+// ResourceGroups returns the resource groups of a subscription that hold
+// cached resources, ordered by name.
+func (db *DB) ResourceGroups(ctx context.Context, subscriptionID string) ([]ResourceGroup, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT resourceGroup, COUNT(*)
+		FROM resources
+		WHERE LOWER(subscriptionId) = LOWER(?) AND resourceGroup != ''
+		GROUP BY LOWER(resourceGroup)
+		ORDER BY resourceGroup COLLATE NOCASE ASC;`, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("query resource groups: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var groups []ResourceGroup
+	for rows.Next() {
+		var g ResourceGroup
+		if err := rows.Scan(&g.Name, &g.Resources); err != nil {
+			return nil, fmt.Errorf("scan resource group: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration: %w", err)
+	}
+	return groups, nil
+}
+
+// ChangeStats counts how a subscription's cached resources changed during a sync.
+type ChangeStats struct {
+	Added   int
+	Updated int
+	Removed int
+}
+
+// ReplaceSubscriptionResources makes resources the complete cached set for
+// subscriptionID: new rows are inserted, existing rows refreshed and rows no
+// longer present removed, all in one transaction.
+func (db *DB) ReplaceSubscriptionResources(ctx context.Context, subscriptionID string, resources []Resource) (ChangeStats, error) {
+	return db.storeSubscriptionResources(ctx, subscriptionID, resources, true)
+}
+
+// MergeSubscriptionResources inserts and refreshes resources like
+// ReplaceSubscriptionResources but keeps cached rows that are not in
+// resources, for listings known to be incomplete.
+func (db *DB) MergeSubscriptionResources(ctx context.Context, subscriptionID string, resources []Resource) (ChangeStats, error) {
+	return db.storeSubscriptionResources(ctx, subscriptionID, resources, false)
+}
+
+// storeSubscriptionResources writes resources of subscriptionID and, with
+// prune, removes the cached rows that are not among them.
+func (db *DB) storeSubscriptionResources(ctx context.Context, subscriptionID string, resources []Resource, prune bool) (ChangeStats, error) {
+	var stats ChangeStats
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, fmt.Errorf("begin transaction: %w", err)
+	}
+	rollback := func(err error) (ChangeStats, error) {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return ChangeStats{}, fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return ChangeStats{}, err
+	}
+
+	query := `
+		SELECT ` + resourceColumns + `
+		FROM ` + resourceTables + `
+		WHERE LOWER(subscriptionId) = LOWER(?);`
+	rows, err := tx.QueryContext(ctx, query, subscriptionID)
+	if err != nil {
+		return rollback(fmt.Errorf("query existing resources: %w", err))
+	}
+	current, err := scanResources(rows)
+	_ = rows.Close()
+	if err != nil {
+		return rollback(err)
+	}
+
+	// Keyed by lower-case ID: the id column ignores case, so a resource whose
+	// ID comes back with a different case replaces its old row.
+	existing := make(map[string]Resource, len(current))
+	for _, r := range current {
+		existing[strings.ToLower(r.ID)] = r
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT OR REPLACE INTO resources
+		(id, name, type, subscriptionId, resourceGroup, location, tenantId, sku, tags, parentId, updatedAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP);
+	`)
+	if err != nil {
+		return rollback(fmt.Errorf("prepare insert: %w", err))
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	for _, r := range resources {
+		tags, err := encodeTags(r.Tags)
+		if err != nil {
+			return rollback(fmt.Errorf("insert resource %q: %w", r.ID, err))
+		}
+		if _, err := stmt.ExecContext(ctx, r.ID, r.Name, r.Type, r.SubscriptionID, r.ResourceGroup, r.Location, r.TenantID, r.SKU, tags, armid.ParentID(r.ID)); err != nil {
+			return rollback(fmt.Errorf("insert resource %q: %w", r.ID, err))
+		}
+
+		key := strings.ToLower(r.ID)
+		old, ok := existing[key]
+		switch {
+		case !ok:
+			stats.Added++
+		case !sameResource(old, r):
+			stats.Updated++
+		}
+		delete(existing, key)
+	}
+
+	if prune {
+		for _, old := range existing {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM resources WHERE id = ?;`, old.ID); err != nil {
+				return rollback(fmt.Errorf("delete resource %q: %w", old.ID, err))
+			}
+			stats.Removed++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ChangeStats{}, fmt.Errorf("commit transaction: %w", err)
+	}
+	return stats, nil
+}
+
+// sameResource compares the synced fields of two resources, ignoring UpdatedAt.
+func sameResource(a, b Resource) bool {
+	return a.ID == b.ID && a.Name == b.Name && a.Type == b.Type &&
+		a.SubscriptionID == b.SubscriptionID && a.ResourceGroup == b.ResourceGroup &&
+		a.Location == b.Location && a.TenantID == b.TenantID && a.SKU == b.SKU &&
+		maps.Equal(a.Tags, b.Tags)
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+)
+
+// Subscription is an Azure subscription as last listed by a sync.
+type Subscription struct {
+	ID          string
+	DisplayName string
+	// State is Enabled, Warned, PastDue, Disabled or Deleted.
+	State    string
+	TenantID string
+	Tags     map[string]string
+	// ManagementGroupID is the management group the subscription is directly
+	// in, or "" if unknown.
+	ManagementGroupID string
+}
+
+// Label returns the display name, or the ID if the name is unknown.
+func (s Subscription) Label() string {
+	if s.DisplayName != "" {
+		return s.DisplayName
+	}
+	return s.ID
+}
+
+// ReplaceSubscriptions makes subs the complete set of cached subscriptions.
+func (db *DB) ReplaceSubscriptions(ctx context.Context, subs []Subscription) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	rollback := func(err error) error {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM subscriptions;`); err != nil {
+		return rollback(fmt.Errorf("clear subscriptions: %w", err))
+	}
+	for _, s := range subs {
+		tags, err := encodeTags(s.Tags)
+		if err != nil {
+			return rollback(fmt.Errorf("insert subscription %q: %w", s.ID, err))
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR REPLACE INTO subscriptions (id, displayName, state, tenantId, tags, managementGroupId, updatedAt)
+			VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP);`,
+			s.ID, s.DisplayName, s.State, s.TenantID, tags, s.ManagementGroupID); err != nil {
+			return rollback(fmt.Errorf("insert subscription %q: %w", s.ID, err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListSubscriptions returns the cached subscriptions ordered by display name.
+func (db *DB) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT id, displayName, state, tenantId, tags, managementGroupId
+		FROM subscriptions
+		ORDER BY displayName COLLATE NOCASE ASC, id ASC;`)
+	if err != nil {
+		return nil, fmt.Errorf("query subscriptions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var subs []Subscription
+	for rows.Next() {
+		var s Subscription
+		var tags string
+		if err := rows.Scan(&s.ID, &s.DisplayName, &s.State, &s.TenantID, &tags, &s.ManagementGroupID); err != nil {
+			return nil, fmt.Errorf("scan subscription: %w", err)
+		}
+		if s.Tags, err = decodeTags(tags); err != nil {
+			return nil, fmt.Errorf("subscription %q: %w", s.ID, err)
+		}
+		subs = append(subs, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration: %w", err)
+	}
+	return subs, nil
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	tmp := t.TempDir()
+	_ = os.Setenv("XDG_CACHE_HOME", tmp)
+	defer func() {
+		_ = os.Unsetenv("XDG_CACHE_HOME")
+	}()
+
+	db, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func(db *DB) {
+		_ = db.Close()
+	}(db)
+
+	if err := db.InsertResources(ctx, []Resource{
+		{ID: "1", Name: "app", Type: "Microsoft.Web/sites", SubscriptionID: "AAAA"},
+		{ID: "2", Name: "kv", Type: "Microsoft.KeyVault/vaults", SubscriptionID: "bbbb"},
+	}); err != nil {
+		t.Fatalf("insert resources: %v", err)
+	}
+
+	subs := []Subscription{
+		{ID: "aaaa", DisplayName: "Production", State: "Enabled", TenantID: "t1", Tags: map[string]string{"owner": "ops"}},
+		{ID: "cccc", DisplayName: "Archive", State: "Disabled", TenantID: "t1"},
+	}
+	if err := db.ReplaceSubscriptions(ctx, subs); err != nil {
+		t.Fatalf("replace subscriptions: %v", err)
+	}
+
+	got, err := db.ListSubscriptions(ctx)
+	if err != nil {
+		t.Fatalf("list subscriptions: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "cccc" || got[1].Tags["owner"] != "ops" || got[1].State != "Enabled" {
+		t.Fatalf("unexpected subscriptions: %+v", got)
+	}
+
+	r, err := db.FindResourceByID(ctx, "1")
+	if err != nil || r == nil {
+		t.Fatalf("find resource: %v, %v", r, err)
+	}
+	if r.SubscriptionName != "Production" || r.SubscriptionLabel() != "Production" {
+		t.Fatalf("expected the joined subscription name, got %+v", r)
+	}
+	r, err = db.FindResourceByID(ctx, "2")
+	if err != nil || r == nil {
+		t.Fatalf("find resource: %v, %v", r, err)
+	}
+	if r.SubscriptionName != "" || r.SubscriptionLabel() != "bbbb" {
+		t.Fatalf("expected no name for an unknown subscription, got %+v", r)
+	}
+
+	if err := db.ReplaceSubscriptions(ctx, subs[1:]); err != nil {
+		t.Fatalf("replace subscriptions: %v", err)
+	}
+	if got, _ := db.ListSubscriptions(ctx); len(got) != 1 || got[0].Label() != "Archive" {
+		t.Fatalf("expected only Archive after replacing, got %+v", got)
+	}
+}
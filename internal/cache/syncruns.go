@@ -0,0 +1,261 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RunStatus is the lifecycle state of a sync run.
+type RunStatus string
+
+const (
+	RunRunning   RunStatus = "running"
+	RunCompleted RunStatus = "completed"
+	RunCancelled RunStatus = "cancelled"
+	RunFailed    RunStatus = "failed"
+)
+
+// SyncRun is one invocation of the syncer. A resumed run keeps its ID, so its
+// totals cover every subscription synced across all attempts.
+type SyncRun struct {
+	ID            int64
+	Status        RunStatus
+	StartedAt     time.Time
+	FinishedAt    time.Time
+	Subscriptions int
+	Failed        int
+	Resources     int
+	Err           string
+}
+
+// Resumable reports whether the run stopped before syncing every subscription.
+// A run still marked as running was killed without recording its end.
+func (r SyncRun) Resumable() bool {
+	return r.Status == RunCancelled || r.Status == RunFailed || r.Status == RunRunning
+}
+
+// SubscriptionSync records the outcome of syncing one subscription within a run.
+type SubscriptionSync struct {
+	RunID          int64
+	SubscriptionID string
+	DisplayName    string
+	StartedAt      time.Time
+	FinishedAt     time.Time
+	Duration       time.Duration
+	Resources      int
+	Added          int
+	Updated        int
+	Removed        int
+	Err            string
+}
+
+// SubscriptionStatus summarises the sync history of a subscription.
+type SubscriptionStatus struct {
+	SubscriptionID string
+	DisplayName    string
+	// LastSuccess is the finish time of the latest successful sync, zero if none.
+	LastSuccess time.Time
+	// Resources is the resource count of the latest successful sync.
+	Resources int
+	// LastAttempt is the finish time of the latest sync, successful or not.
+	LastAttempt time.Time
+	// LastErr is the error of the latest sync; empty if it succeeded.
+	LastErr string
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(sqliteTimestamp)
+}
+
+func parseTimestamp(raw sql.NullString) (time.Time, error) {
+	if !raw.Valid || raw.String == "" {
+		return time.Time{}, nil
+	}
+	// The driver may hand back RFC 3339 for TIMESTAMP columns; accept both.
+	if t, err := time.Parse(time.RFC3339, raw.String); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.ParseInLocation(sqliteTimestamp, raw.String, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse timestamp %q: %w", raw.String, err)
+	}
+	return t, nil
+}
+
+// BeginSyncRun inserts a new run and returns its ID.
+func (db *DB) BeginSyncRun(ctx context.Context, startedAt time.Time) (int64, error) {
+	res, err := db.conn.ExecContext(ctx, `INSERT INTO sync_runs (startedAt, status) VALUES (?, ?);`, formatTimestamp(startedAt), RunRunning)
+	if err != nil {
+		return 0, fmt.Errorf("insert sync run: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("sync run id: %w", err)
+	}
+	return id, nil
+}
+
+// FinishSyncRun stores the status, error and finish time of run. Totals are
+// computed from the subscriptions recorded for the run.
+func (db *DB) FinishSyncRun(ctx context.Context, run SyncRun) error {
+	_, err := db.conn.ExecContext(ctx, `
+		UPDATE sync_runs
+		SET finishedAt = ?, status = ?, error = ?,
+		    subscriptions = (SELECT COUNT(*) FROM subscription_sync WHERE runId = sync_runs.id),
+		    failed = (SELECT COUNT(*) FROM subscription_sync WHERE runId = sync_runs.id AND error <> ''),
+		    resources = (SELECT COALESCE(SUM(resources), 0) FROM subscription_sync WHERE runId = sync_runs.id AND error = '')
+		WHERE id = ?;`,
+		formatTimestamp(run.FinishedAt), run.Status, run.Err, run.ID)
+	if err != nil {
+		return fmt.Errorf("update sync run %d: %w", run.ID, err)
+	}
+	return nil
+}
+
+// ReopenSyncRun marks an unfinished run as running again so it can be resumed.
+func (db *DB) ReopenSyncRun(ctx context.Context, id int64) error {
+	_, err := db.conn.ExecContext(ctx, `
+		UPDATE sync_runs SET finishedAt = NULL, status = ?, error = '' WHERE id = ?;`, RunRunning, id)
+	if err != nil {
+		return fmt.Errorf("reopen sync run %d: %w", id, err)
+	}
+	return nil
+}
+
+// CompletedSubscriptions returns the IDs of subscriptions synced successfully in run id.
+func (db *DB) CompletedSubscriptions(ctx context.Context, id int64) (map[string]bool, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT subscriptionId FROM subscription_sync WHERE runId = ? AND error = '';`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query completed subscriptions: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	done := map[string]bool{}
+	for rows.Next() {
+		var subID string
+		if err := rows.Scan(&subID); err != nil {
+			return nil, fmt.Errorf("scan completed subscription: %w", err)
+		}
+		done[subID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration: %w", err)
+	}
+	return done, nil
+}
+
+// RecordSubscriptionSync stores the outcome of syncing one subscription.
+func (db *DB) RecordSubscriptionSync(ctx context.Context, s SubscriptionSync) error {
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT OR REPLACE INTO subscription_sync
+		(runId, subscriptionId, displayName, startedAt, finishedAt, durationMs, resources, added, updated, removed, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		s.RunID, s.SubscriptionID, s.DisplayName,
+		formatTimestamp(s.StartedAt), formatTimestamp(s.FinishedAt), s.Duration.Milliseconds(),
+		s.Resources, s.Added, s.Updated, s.Removed, s.Err)
+	if err != nil {
+		return fmt.Errorf("insert subscription sync %q: %w", s.SubscriptionID, err)
+	}
+	return nil
+}
+
+// LastSyncRun returns the most recently started run, or nil if there is none.
+func (db *DB) LastSyncRun(ctx context.Context) (*SyncRun, error) {
+	row := db.conn.QueryRowContext(ctx, `
+		SELECT id, status, startedAt, finishedAt, subscriptions, failed, resources, error
+		FROM sync_runs
+		ORDER BY id DESC
+		LIMIT 1;`)
+
+	var run SyncRun
+	var started, finished sql.NullString
+	if err := row.Scan(&run.ID, &run.Status, &started, &finished, &run.Subscriptions, &run.Failed, &run.Resources, &run.Err); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan sync run: %w", err)
+	}
+
+	var err error
+	if run.StartedAt, err = parseTimestamp(started); err != nil {
+		return nil, err
+	}
+	if run.FinishedAt, err = parseTimestamp(finished); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// SubscriptionStatuses returns the sync history summary of every subscription
+// that was ever synced, ordered by display name.
+func (db *DB) SubscriptionStatuses(ctx context.Context) ([]SubscriptionStatus, error) {
+	// The current name from the subscriptions table wins over the one
+	// recorded with the sync.
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT ss.subscriptionId, COALESCE(NULLIF(s.displayName, ''), ss.displayName), ss.finishedAt, ss.resources, ss.error
+		FROM subscription_sync ss LEFT JOIN subscriptions s ON s.id = ss.subscriptionId
+		ORDER BY ss.finishedAt DESC, ss.runId DESC;`)
+	if err != nil {
+		return nil, fmt.Errorf("query subscription sync: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	// Rows arrive newest first, so the first row per subscription is its latest attempt.
+	byID := map[string]*SubscriptionStatus{}
+	var order []string
+	for rows.Next() {
+		var (
+			id, name, errText string
+			finished          sql.NullString
+			resources         int
+		)
+		if err := rows.Scan(&id, &name, &finished, &resources, &errText); err != nil {
+			return nil, fmt.Errorf("scan subscription sync: %w", err)
+		}
+		finishedAt, err := parseTimestamp(finished)
+		if err != nil {
+			return nil, err
+		}
+
+		st, ok := byID[id]
+		if !ok {
+			st = &SubscriptionStatus{SubscriptionID: id, DisplayName: name, LastAttempt: finishedAt, LastErr: errText}
+			byID[id] = st
+			order = append(order, id)
+		}
+		if errText == "" && st.LastSuccess.IsZero() {
+			st.LastSuccess = finishedAt
+			st.Resources = resources
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration: %w", err)
+	}
+
+	statuses := make([]SubscriptionStatus, 0, len(order))
+	for _, id := range order {
+		statuses = append(statuses, *byID[id])
+	}
+	sortStatuses(statuses)
+	return statuses, nil
+}
+
+func sortStatuses(s []SubscriptionStatus) {
+	label := func(st SubscriptionStatus) string {
+		if st.DisplayName != "" {
+			return strings.ToLower(st.DisplayName)
+		}
+		return strings.ToLower(st.SubscriptionID)
+	}
+	sort.SliceStable(s, func(i, j int) bool { return label(s[i]) < label(s[j]) })
+}
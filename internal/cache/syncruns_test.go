@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionStatuses(t *testing.T) {
+	ctx := context.Background()
+	tmp := t.TempDir()
+	_ = os.Setenv("XDG_CACHE_HOME", tmp)
+	defer func() {
+		_ = os.Unsetenv("XDG_CACHE_HOME")
+	}()
+
+	db, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func(db *DB) {
+		_ = db.Close()
+	}(db)
+
+	first := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+
+	run1, err := db.BeginSyncRun(ctx, first)
+	if err != nil {
+		t.Fatalf("begin run: %v", err)
+	}
+	for _, s := range []SubscriptionSync{
+		{RunID: run1, SubscriptionID: "sub-a", DisplayName: "prod", StartedAt: first, FinishedAt: first.Add(time.Minute), Resources: 10},
+		{RunID: run1, SubscriptionID: "sub-b", DisplayName: "dev", StartedAt: first, FinishedAt: first.Add(time.Minute), Resources: 3},
+	} {
+		if err := db.RecordSubscriptionSync(ctx, s); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+	if err := db.FinishSyncRun(ctx, SyncRun{ID: run1, Status: RunCompleted, FinishedAt: first.Add(2 * time.Minute)}); err != nil {
+		t.Fatalf("finish run: %v", err)
+	}
+
+	run2, err := db.BeginSyncRun(ctx, second)
+	if err != nil {
+		t.Fatalf("begin run: %v", err)
+	}
+	for _, s := range []SubscriptionSync{
+		{RunID: run2, SubscriptionID: "sub-a", DisplayName: "prod", StartedAt: second, FinishedAt: second.Add(time.Minute), Err: "429 too many requests"},
+		{RunID: run2, SubscriptionID: "sub-b", DisplayName: "dev", StartedAt: second, FinishedAt: second.Add(time.Minute), Resources: 4},
+	} {
+		if err := db.RecordSubscriptionSync(ctx, s); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	last, err := db.LastSyncRun(ctx)
+	if err != nil {
+		t.Fatalf("last run: %v", err)
+	}
+	if last == nil || last.ID != run2 || !last.StartedAt.Equal(second) || !last.FinishedAt.IsZero() {
+		t.Fatalf("unexpected last run: %+v", last)
+	}
+	if last.Status != RunRunning || !last.Resumable() {
+		t.Fatalf("expected unfinished run to be resumable, got %+v", last)
+	}
+
+	statuses, err := db.SubscriptionStatuses(ctx)
+	if err != nil {
+		t.Fatalf("statuses: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 statuses, got %+v", statuses)
+	}
+
+	dev, prod := statuses[0], statuses[1]
+	if dev.DisplayName != "dev" || dev.Resources != 4 || !dev.LastSuccess.Equal(second.Add(time.Minute)) || dev.LastErr != "" {
+		t.Fatalf("unexpected dev status: %+v", dev)
+	}
+	if prod.DisplayName != "prod" || prod.Resources != 10 || !prod.LastSuccess.Equal(first.Add(time.Minute)) || prod.LastErr == "" {
+		t.Fatalf("unexpected prod status: %+v", prod)
+	}
+
+	if err := db.ReplaceSubscriptions(ctx, []Subscription{{ID: dev.SubscriptionID, DisplayName: "Development"}}); err != nil {
+		t.Fatalf("replace subscriptions: %v", err)
+	}
+	statuses, err = db.SubscriptionStatuses(ctx)
+	if err != nil {
+		t.Fatalf("statuses: %v", err)
+	}
+	if statuses[0].DisplayName != "Development" || statuses[1].DisplayName != "prod" {
+		t.Fatalf("expected the current subscription name to win, got %+v", statuses)
+	}
+}
+
+func TestFinishAndResumeSyncRun(t *testing.T) {
+	ctx := context.Background()
+	tmp := t.TempDir()
+	_ = os.Setenv("XDG_CACHE_HOME", tmp)
+	defer func() {
+		_ = os.Unsetenv("XDG_CACHE_HOME")
+	}()
+
+	db, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func(db *DB) {
+		_ = db.Close()
+	}(db)
+
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	id, err := db.BeginSyncRun(ctx, now)
+	if err != nil {
+		t.Fatalf("begin run: %v", err)
+	}
+	for _, s := range []SubscriptionSync{
+		{RunID: id, SubscriptionID: "sub-a", StartedAt: now, FinishedAt: now, Resources: 7},
+		{RunID: id, SubscriptionID: "sub-b", StartedAt: now, FinishedAt: now, Err: "boom"},
+	} {
+		if err := db.RecordSubscriptionSync(ctx, s); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+	if err := db.FinishSyncRun(ctx, SyncRun{ID: id, Status: RunCancelled, FinishedAt: now, Err: "context canceled"}); err != nil {
+		t.Fatalf("finish run: %v", err)
+	}
+
+	last, err := db.LastSyncRun(ctx)
+	if err != nil {
+		t.Fatalf("last run: %v", err)
+	}
+	if last.Status != RunCancelled || last.Subscriptions != 2 || last.Failed != 1 || last.Resources != 7 {
+		t.Fatalf("unexpected totals: %+v", last)
+	}
+
+	done, err := db.CompletedSubscriptions(ctx, id)
+	if err != nil {
+		t.Fatalf("completed subscriptions: %v", err)
+	}
+	if len(done) != 1 || !done["sub-a"] {
+		t.Fatalf("expected only sub-a to be completed, got %v", done)
+	}
+
+	if err := db.ReopenSyncRun(ctx, id); err != nil {
+		t.Fatalf("reopen run: %v", err)
+	}
+	last, err = db.LastSyncRun(ctx)
+	if err != nil {
+		t.Fatalf("last run: %v", err)
+	}
+	if last.Status != RunRunning || !last.FinishedAt.IsZero() || last.Err != "" {
+		t.Fatalf("expected reopened run, got %+v", last)
+	}
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+)
+
+// Tenant is a Microsoft Entra tenant as last listed by a sync.
+type Tenant struct {
+	ID          string
+	DisplayName string
+	// DefaultDomain is e.g. "contoso.onmicrosoft.com".
+	DefaultDomain string
+}
+
+// ReplaceTenants makes tenants the complete set of cached tenants.
+func (db *DB) ReplaceTenants(ctx context.Context, tenants []Tenant) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	rollback := func(err error) error {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM tenants;`); err != nil {
+		return rollback(fmt.Errorf("clear tenants: %w", err))
+	}
+	for _, t := range tenants {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR REPLACE INTO tenants (id, displayName, defaultDomain, updatedAt)
+			VALUES (?, ?, ?, CURRENT_TIMESTAMP);`,
+			t.ID, t.DisplayName, t.DefaultDomain); err != nil {
+			return rollback(fmt.Errorf("insert tenant %q: %w", t.ID, err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListTenants returns the cached tenants ordered by display name.
+func (db *DB) ListTenants(ctx context.Context) ([]Tenant, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT id, displayName, defaultDomain
+		FROM tenants
+		ORDER BY displayName COLLATE NOCASE ASC, id ASC;`)
+	if err != nil {
+		return nil, fmt.Errorf("query tenants: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var tenants []Tenant
+	for rows.Next() {
+		var t Tenant
+		if err := rows.Scan(&t.ID, &t.DisplayName, &t.DefaultDomain); err != nil {
+			return nil, fmt.Errorf("scan tenant: %w", err)
+		}
+		tenants = append(tenants, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration: %w", err)
+	}
+	return tenants, nil
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestTenants(t *testing.T) {
+	ctx := context.Background()
+	tmp := t.TempDir()
+	_ = os.Setenv("XDG_CACHE_HOME", tmp)
+	defer func() {
+		_ = os.Unsetenv("XDG_CACHE_HOME")
+	}()
+
+	db, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func(db *DB) {
+		_ = db.Close()
+	}(db)
+
+	if err := db.InsertResources(ctx, []Resource{
+		{ID: "1", Name: "app", Type: "Microsoft.Web/sites", SubscriptionID: "sub1", TenantID: "T1"},
+		{ID: "2", Name: "kv", Type: "Microsoft.KeyVault/vaults", SubscriptionID: "sub2", TenantID: "t2"},
+	}); err != nil {
+		t.Fatalf("insert resources: %v", err)
+	}
+	if err := db.ReplaceTenants(ctx, []Tenant{{ID: "t1", DisplayName: "Contoso", DefaultDomain: "contoso.onmicrosoft.com"}}); err != nil {
+		t.Fatalf("replace tenants: %v", err)
+	}
+
+	tenants, err := db.ListTenants(ctx)
+	if err != nil || len(tenants) != 1 || tenants[0].DisplayName != "Contoso" {
+		t.Fatalf("unexpected tenants: %+v, %v", tenants, err)
+	}
+
+	r, err := db.FindResourceByID(ctx, "1")
+	if err != nil || r == nil {
+		t.Fatalf("find resource: %v, %v", r, err)
+	}
+	if r.TenantLabel() != "Contoso" || r.PortalTenant() != "contoso.onmicrosoft.com" {
+		t.Fatalf("expected the joined tenant, got %+v", r)
+	}
+	r, err = db.FindResourceByID(ctx, "2")
+	if err != nil || r == nil {
+		t.Fatalf("find resource: %v, %v", r, err)
+	}
+	if r.TenantLabel() != "t2" || r.PortalTenant() != "t2" {
+		t.Fatalf("expected the tenant ID for an unknown tenant, got %+v", r)
+	}
+}
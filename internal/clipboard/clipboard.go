@@ -0,0 +1,162 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Tool names accepted by SetTool and the clipboard.tool config key.
+const (
+	Auto   = "auto"
+	WlCopy = "wl-copy"
+	Xclip  = "xclip"
+	Xsel   = "xsel"
+	Pbcopy = "pbcopy"
+	OSC52  = "osc52"
+)
+
+// commands maps external tools to the argv that reads the clipboard content from stdin.
+var commands = map[string][]string{
+	WlCopy: {"wl-copy"},
+	Xclip:  {"xclip", "-selection", "clipboard"},
+	Xsel:   {"xsel", "--clipboard", "--input"},
+	Pbcopy: {"pbcopy"},
+}
+
+var (
+	mu   sync.Mutex
+	tool = Auto
+)
+
+// openTTY returns the terminal OSC 52 sequences are written to. Tests replace it.
+var openTTY = func() (io.WriteCloser, error) {
+	return os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+}
+
+// SetTool overrides clipboard detection. Auto (or "") restores detection. An
+// unknown name is reported by Copy, so only copying fails.
+func SetTool(name string) {
+	if name == "" {
+		name = Auto
+	}
+
+	mu.Lock()
+	tool = name
+	mu.Unlock()
+}
+
+// Copy writes text to the clipboard using the configured or detected tool.
+func Copy(text string) error {
+	mu.Lock()
+	name := tool
+	mu.Unlock()
+
+	switch name {
+	case Auto:
+		detected, err := Detect()
+		if err != nil {
+			return err
+		}
+		name = detected
+	case OSC52:
+	default:
+		if _, ok := commands[name]; !ok {
+			return fmt.Errorf("unknown clipboard tool %q (use auto, wl-copy, xclip, xsel, pbcopy or osc52)", name)
+		}
+	}
+
+	if name == OSC52 {
+		return copyOSC52(text)
+	}
+	return copyCommand(commands[name], text)
+}
+
+// Detect picks a clipboard tool for the current session. Over SSH without a
+// forwarded display the local tools would write to the remote machine's
+// clipboard, so OSC 52 is used to reach the user's terminal instead.
+func Detect() (string, error) {
+	wayland := os.Getenv("WAYLAND_DISPLAY") != ""
+	x11 := os.Getenv("DISPLAY") != ""
+	ssh := os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+
+	if ssh && !wayland && !x11 {
+		return OSC52, nil
+	}
+
+	var candidates []string
+	if wayland {
+		candidates = append(candidates, WlCopy)
+	}
+	if x11 {
+		candidates = append(candidates, Xclip, Xsel)
+	}
+	candidates = append(candidates, Pbcopy)
+
+	for _, c := range candidates {
+		if _, err := exec.LookPath(commands[c][0]); err == nil {
+			return c, nil
+		}
+	}
+
+	if isatty.IsTerminal(os.Stdout.Fd()) {
+		return OSC52, nil
+	}
+	return "", fmt.Errorf("no clipboard tool found (install wl-copy, xclip, xsel or pbcopy, or set clipboard.tool: osc52)")
+}
+
+// copyCommand runs argv with text on stdin. xclip, xsel and wl-copy fork a
+// process that serves the selection and inherits the output descriptors, so
+// output is never read from a pipe: Wait would block until that process
+// exits, i.e. until something else is copied. Stdout is discarded and stderr
+// goes to a temporary file that is read for the error message.
+func copyCommand(argv []string, text string) error {
+	stderr, err := os.CreateTemp("", "azf-clipboard-*")
+	if err != nil {
+		return fmt.Errorf("%s: %w", argv[0], err)
+	}
+	defer func() {
+		_ = stderr.Close()
+		_ = os.Remove(stderr.Name())
+	}()
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		out, _ := os.ReadFile(stderr.Name())
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %w: %s", argv[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", argv[0], err)
+	}
+	return nil
+}
+
+// copyOSC52 asks the terminal emulator to set the clipboard. Inside tmux the
+// sequence is wrapped in a passthrough so it reaches the outer terminal.
+func copyOSC52(text string) error {
+	tty, err := openTTY()
+	if err != nil {
+		return fmt.Errorf("open terminal for OSC 52: %w", err)
+	}
+	defer func() {
+		_ = tty.Close()
+	}()
+
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;\x1b" + seq + "\x1b\\"
+	}
+
+	if _, err := io.WriteString(tty, seq); err != nil {
+		return fmt.Errorf("write OSC 52: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package clipboard
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTool installs an executable called name on an isolated PATH that saves
+// its arguments and stdin to files in the returned directory.
+func fakeTool(t *testing.T, names ...string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake clipboard tools are shell scripts")
+	}
+	// Resolve cat before PATH is replaced so the fakes can still use it.
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skipf("cat not available: %v", err)
+	}
+
+	dir := t.TempDir()
+	for _, name := range names {
+		script := "#!/bin/sh\necho \"$@\" > \"" + filepath.Join(dir, name+".args") + "\"\n" + cat + " > \"" + filepath.Join(dir, name+".stdin") + "\"\n"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatalf("write fake %s: %v", name, err)
+		}
+	}
+	t.Setenv("PATH", dir)
+	return dir
+}
+
+func clearSession(t *testing.T) {
+	t.Helper()
+	for _, k := range []string{"WAYLAND_DISPLAY", "DISPLAY", "SSH_TTY", "SSH_CONNECTION", "TMUX"} {
+		t.Setenv(k, "")
+	}
+	t.Cleanup(func() { SetTool(Auto) })
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(b)
+}
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		name  string
+		env   map[string]string
+		tools []string
+		want  string
+	}{
+		{"wayland", map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, []string{"wl-copy", "xclip"}, WlCopy},
+		{"x11 prefers xclip", map[string]string{"DISPLAY": ":0"}, []string{"xclip", "xsel"}, Xclip},
+		{"x11 falls back to xsel", map[string]string{"DISPLAY": ":0"}, []string{"xsel"}, Xsel},
+		{"macOS", nil, []string{"pbcopy"}, Pbcopy},
+		{"ssh without display", map[string]string{"SSH_TTY": "/dev/pts/1"}, []string{"xclip", "pbcopy"}, OSC52},
+		{"ssh with forwarded X", map[string]string{"SSH_CONNECTION": "1 2 3 4", "DISPLAY": "localhost:10"}, []string{"xclip"}, Xclip},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearSession(t)
+			fakeTool(t, tc.tools...)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			got, err := Detect()
+			if err != nil {
+				t.Fatalf("detect: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCopyUsesDetectedTool(t *testing.T) {
+	clearSession(t)
+	dir := fakeTool(t, "xclip")
+	t.Setenv("DISPLAY", ":0")
+
+	if err := Copy("/subscriptions/sub1"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if got := readFile(t, filepath.Join(dir, "xclip.stdin")); got != "/subscriptions/sub1" {
+		t.Fatalf("unexpected clipboard content %q", got)
+	}
+	if got := strings.TrimSpace(readFile(t, filepath.Join(dir, "xclip.args"))); got != "-selection clipboard" {
+		t.Fatalf("unexpected xclip args %q", got)
+	}
+}
+
+func TestCopyHonoursOverride(t *testing.T) {
+	clearSession(t)
+	dir := fakeTool(t, "xsel", "wl-copy")
+	t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+
+	SetTool(Xsel)
+	if err := Copy("hello"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if got := readFile(t, filepath.Join(dir, "xsel.stdin")); got != "hello" {
+		t.Fatalf("unexpected clipboard content %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "wl-copy.stdin")); err == nil {
+		t.Fatal("expected detected wl-copy to be bypassed by the override")
+	}
+
+	SetTool("clippy")
+	if err := Copy("hello"); err == nil || !strings.Contains(err.Error(), `unknown clipboard tool "clippy"`) {
+		t.Fatalf("expected an unknown tool error from Copy, got %v", err)
+	}
+}
+
+// scriptTool installs an executable called name running script on an
+// isolated PATH, with the directory of sleep still reachable.
+func scriptTool(t *testing.T, name, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake clipboard tools are shell scripts")
+	}
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatalf("write fake %s: %v", name, err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+filepath.Dir(sleep))
+}
+
+func TestCopyDoesNotWaitForForkedTool(t *testing.T) {
+	clearSession(t)
+	// Like xclip, leave a process behind that holds stdout and stderr open.
+	scriptTool(t, "xclip", "sleep 5 &")
+	t.Setenv("DISPLAY", ":0")
+
+	start := time.Now()
+	if err := Copy("hello"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("copy waited %s for the background process", d)
+	}
+}
+
+func TestCopyReportsToolError(t *testing.T) {
+	clearSession(t)
+	scriptTool(t, "xclip", "echo 'Error: cannot open display' >&2; exit 1")
+	t.Setenv("DISPLAY", ":0")
+
+	err := Copy("hello")
+	if err == nil || !strings.Contains(err.Error(), "cannot open display") {
+		t.Fatalf("expected the tool's message, got %v", err)
+	}
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestCopyOSC52(t *testing.T) {
+	clearSession(t)
+
+	var buf bytes.Buffer
+	prev := openTTY
+	openTTY = func() (io.WriteCloser, error) { return nopCloser{&buf}, nil }
+	t.Cleanup(func() { openTTY = prev })
+
+	SetTool(OSC52)
+	if err := Copy("hi"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if got, want := buf.String(), "\x1b]52;c;aGk=\a"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	buf.Reset()
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	if err := Copy("hi"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if got, want := buf.String(), "\x1bPtmux;\x1b\x1b]52;c;aGk=\a\x1b\\"; got != want {
+		t.Fatalf("expected tmux passthrough %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,74 @@
+// Package display formats values for terminal output: ages, and text fitted
+// to a number of terminal cells. Wide characters, such as CJK, take two cells.
+package display
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Age formats d as a coarse age such as "3d", "5h" or "12m".
+func Age(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	}
+}
+
+// Styles for where Truncate marks the cut.
+const (
+	TruncateEnd    = "end"
+	TruncateMiddle = "middle"
+	TruncateStart  = "start"
+)
+
+// Width returns the number of cells s takes in a terminal.
+func Width(s string) int {
+	return runewidth.StringWidth(s)
+}
+
+// Pad appends spaces to s until it is width cells wide.
+func Pad(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-Width(s), 0))
+}
+
+// Truncate shortens s to width cells, marking the cut with "..." at the end,
+// in the middle or at the start depending on style; an unknown style cuts
+// the end.
+func Truncate(s string, width int, style string) string {
+	if Width(s) <= width {
+		return s
+	}
+	if width <= 3 {
+		return runewidth.Truncate(s, max(width, 0), "")
+	}
+
+	keep := width - 3
+	switch style {
+	case TruncateStart:
+		return "..." + tail(s, keep)
+	case TruncateMiddle:
+		head := (keep + 1) / 2
+		return runewidth.Truncate(s, head, "") + "..." + tail(s, keep-head)
+	default:
+		return runewidth.Truncate(s, keep, "") + "..."
+	}
+}
+
+// tail returns the longest suffix of s at most width cells wide.
+func tail(s string, width int) string {
+	runes := []rune(s)
+	i, w := len(runes), 0
+	for i > 0 && w+runewidth.RuneWidth(runes[i-1]) <= width {
+		w += runewidth.RuneWidth(runes[i-1])
+		i--
+	}
+	return string(runes[i:])
+}
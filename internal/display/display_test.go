@@ -0,0 +1,64 @@
+package display
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAge(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{30 * time.Second, "0m"},
+		{12 * time.Minute, "12m"},
+		{5*time.Hour + 59*time.Minute, "5h"},
+		{73 * time.Hour, "3d"},
+	}
+	for _, tt := range tests {
+		if got := Age(tt.in); got != tt.want {
+			t.Errorf("Age(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		style, want string
+	}{
+		{TruncateEnd, "abcd..."},
+		{TruncateMiddle, "ab...ij"},
+		{TruncateStart, "...ghij"},
+	}
+	for _, tt := range tests {
+		if got := Truncate("abcdefghij", 7, tt.style); got != tt.want {
+			t.Errorf("Truncate(%s) = %q, want %q", tt.style, got, tt.want)
+		}
+	}
+	if got := Truncate("abc", 7, TruncateEnd); got != "abc" {
+		t.Errorf("expected short values unchanged, got %q", got)
+	}
+
+	// Wide characters take two cells each.
+	wide := []struct {
+		style, want string
+	}{
+		{TruncateEnd, "日本語..."},
+		{TruncateMiddle, "日...リ"},
+		{TruncateStart, "...アプリ"},
+	}
+	for _, tt := range wide {
+		if got := Truncate("日本語アプリ", 9, tt.style); got != tt.want {
+			t.Errorf("Truncate(%s) of wide text = %q, want %q", tt.style, got, tt.want)
+		}
+	}
+}
+
+func TestPad(t *testing.T) {
+	if got := Pad("日本", 6); got != "日本  " {
+		t.Errorf("expected two spaces after four cells, got %q", got)
+	}
+	if got := Pad("abcdef", 3); got != "abcdef" {
+		t.Errorf("expected wide values unchanged, got %q", got)
+	}
+}
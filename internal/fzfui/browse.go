@@ -0,0 +1,157 @@
+package fzfui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chege/azfind/internal/actions"
+	"github.com/chege/azfind/internal/cache"
+	"github.com/chege/azfind/internal/hierarchy"
+	"github.com/chege/azfind/internal/picker"
+)
+
+// browseOpenKey opens the highlighted level of the tree browser in the portal.
+const browseOpenKey = "ctrl-o"
+
+// RunBrowse lets the user drill down from management groups to
+// subscriptions, resource groups and their resources. Enter descends, esc
+// goes back up and ctrl-o opens the highlighted level in the portal. The
+// resource level is the usual picker, whose action ends the browser.
+func RunBrowse(ctx context.Context, opts SearchOptions) error {
+	reg := opts.Actions
+	if reg == nil {
+		reg = actions.Default(actions.Options{})
+	}
+	defaultAction := opts.DefaultAction
+	if defaultAction == "" {
+		defaultAction = actions.OpenPortal
+	}
+
+	db, err := cache.Open(ctx)
+	if err != nil {
+		return fmt.Errorf("open cache: %w", err)
+	}
+	defer func() {
+		if cerr := db.Close(); cerr != nil {
+			fmt.Printf("warning: failed to close cache db: %v\n", cerr)
+		}
+	}()
+
+	roots, err := hierarchy.Load(ctx, db)
+	if err != nil {
+		return err
+	}
+	if len(roots) == 0 {
+		fmt.Println("No cached subscriptions found. Run `azf sync` first.")
+		return nil
+	}
+
+	p, err := newPicker(opts.UI)
+	if err != nil {
+		return err
+	}
+
+	var path []*hierarchy.Node
+	back := func() bool {
+		if len(path) == 0 {
+			return false
+		}
+		path = path[:len(path)-1]
+		return true
+	}
+
+	for {
+		if len(path) > 0 && path[len(path)-1].Kind == hierarchy.KindResourceGroup {
+			rg := path[len(path)-1]
+			resources, err := db.FindResourcesInGroup(ctx, rg.SubscriptionID, rg.ID, -1)
+			if err != nil {
+				return fmt.Errorf("list resource group: %w", err)
+			}
+			selected, err := pick(ctx, db, p, resources, picker.Options{
+				Header:        breadcrumb(path) + " · esc back",
+				Actions:       reg,
+				DefaultAction: defaultAction,
+				Layout:        opts.Layout,
+				Preview:       opts.Preview,
+			})
+			if errors.Is(err, picker.ErrCancelled) {
+				back()
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			return runAction(ctx, db, reg, selected.Action, selected.Resources, opts)
+		}
+
+		nodes, err := browseLevel(ctx, db, roots, path)
+		if err != nil {
+			return err
+		}
+		if len(nodes) == 0 {
+			// Nothing cached below this level; stay where we were.
+			back()
+			continue
+		}
+
+		lines := make([]string, len(nodes))
+		for i, n := range nodes {
+			lines[i] = n.Summary()
+		}
+		header := "enter drill down · " + browseOpenKey + " portal · esc back"
+		if len(path) > 0 {
+			header = breadcrumb(path) + "\n" + header
+		}
+		choice, err := p.List(lines, picker.ListOptions{Prompt: "> ", Header: header, Keys: []string{browseOpenKey}})
+		if errors.Is(err, picker.ErrCancelled) && back() {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		n := nodes[choice.Index]
+		if choice.Key == browseOpenKey {
+			return openNode(ctx, reg, n)
+		}
+		path = append(path, n)
+	}
+}
+
+// browseLevel returns the nodes below the end of path, or roots if path is
+// empty.
+func browseLevel(ctx context.Context, db *cache.DB, roots, path []*hierarchy.Node) ([]*hierarchy.Node, error) {
+	if len(path) == 0 {
+		return roots, nil
+	}
+	cur := path[len(path)-1]
+	if cur.Kind == hierarchy.KindSubscription {
+		nodes, err := hierarchy.ResourceGroups(ctx, db, cur)
+		if err != nil {
+			return nil, fmt.Errorf("list resource groups: %w", err)
+		}
+		return nodes, nil
+	}
+	return cur.Children, nil
+}
+
+// breadcrumb names the levels of path, e.g. "Landing Zones / corp-prod".
+func breadcrumb(path []*hierarchy.Node) string {
+	names := make([]string, len(path))
+	for i, n := range path {
+		names[i] = n.Label()
+	}
+	return strings.Join(names, " / ")
+}
+
+// openNode opens a management group, subscription or resource group in the
+// portal with the registry's open action.
+func openNode(ctx context.Context, reg *actions.Registry, n *hierarchy.Node) error {
+	a, ok := reg.Get(actions.OpenPortal)
+	if !ok {
+		return fmt.Errorf("unknown action %q", actions.OpenPortal)
+	}
+	return actions.RunOn(ctx, a, []cache.Resource{n.Resource()})
+}
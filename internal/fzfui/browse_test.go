@@ -0,0 +1,18 @@
+package fzfui
+
+import (
+	"testing"
+
+	"github.com/chege/azfind/internal/hierarchy"
+)
+
+func TestBreadcrumb(t *testing.T) {
+	path := []*hierarchy.Node{
+		{Kind: hierarchy.KindManagementGroup, ID: "lz", Name: "Landing Zones"},
+		{Kind: hierarchy.KindSubscription, ID: "0000-1111"},
+		{Kind: hierarchy.KindResourceGroup, ID: "rg-app"},
+	}
+	if got, want := breadcrumb(path), "Landing Zones / 0000-1111 / rg-app"; got != want {
+		t.Fatalf("breadcrumb() = %q, want %q", got, want)
+	}
+}
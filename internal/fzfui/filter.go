@@ -0,0 +1,173 @@
+package fzfui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chege/azfind/internal/cache"
+)
+
+// Prefixes of search arguments that restrict the search to subscriptions,
+// e.g. "sub:prod", tenants, e.g. "tenant:contoso", management groups, e.g.
+// "mg:landing-zones", resource types, e.g. "type:keyvault", locations, e.g.
+// "loc:westeurope", or tags, e.g. "tag:owner=team-x" or "tag:owner".
+const (
+	subPrefix    = "sub:"
+	tenantPrefix = "tenant:"
+	mgPrefix     = "mg:"
+	typePrefix   = "type:"
+	locPrefix    = "loc:"
+	tagPrefix    = "tag:"
+)
+
+// filters are the qualifiers of a search, such as "sub:prod".
+type filters struct {
+	// subs match subscription IDs exactly or display names by substring,
+	// ignoring case. A resource passes if it matches any of them.
+	subs []string
+	// tenants match tenant IDs exactly or display names and default domains
+	// by substring, ignoring case.
+	tenants []string
+	// groups are management group IDs or display names. They match the
+	// subscriptions in the groups and their descendants, which resolve
+	// collects in groupSubs by lower-case ID.
+	groups    []string
+	groupSubs map[string]bool
+	// types and locations match resource types and locations by substring,
+	// ignoring case.
+	types     []string
+	locations []string
+	// tags are "key=value", matching a tag value ignoring case, or "key",
+	// matching any resource with the tag. Each key must match one of its
+	// entries.
+	tags []string
+}
+
+// parseArgs splits search arguments into free-text terms and filters.
+func parseArgs(args []string) ([]string, filters) {
+	var terms []string
+	var f filters
+	for _, a := range args {
+		if v, ok := cutPrefixFold(a, subPrefix); ok && v != "" {
+			f.subs = append(f.subs, v)
+			continue
+		}
+		if v, ok := cutPrefixFold(a, tenantPrefix); ok && v != "" {
+			f.tenants = append(f.tenants, v)
+			continue
+		}
+		if v, ok := cutPrefixFold(a, mgPrefix); ok && v != "" {
+			f.groups = append(f.groups, v)
+			continue
+		}
+		if v, ok := cutPrefixFold(a, typePrefix); ok && v != "" {
+			f.types = append(f.types, v)
+			continue
+		}
+		if v, ok := cutPrefixFold(a, locPrefix); ok && v != "" {
+			f.locations = append(f.locations, v)
+			continue
+		}
+		if v, ok := cutPrefixFold(a, tagPrefix); ok && v != "" {
+			f.tags = append(f.tags, v)
+			continue
+		}
+		terms = append(terms, a)
+	}
+	return terms, f
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+// empty reports whether no filter is set.
+func (f filters) empty() bool {
+	return len(f.subs) == 0 && len(f.tenants) == 0 && len(f.groups) == 0 &&
+		len(f.types) == 0 && len(f.locations) == 0 && len(f.tags) == 0
+}
+
+// resolve looks up the subscriptions of the management group filters.
+func (f *filters) resolve(ctx context.Context, db *cache.DB) error {
+	if len(f.groups) == 0 {
+		return nil
+	}
+	f.groupSubs = map[string]bool{}
+	for _, g := range f.groups {
+		ids, err := db.SubscriptionsInManagementGroup(ctx, g)
+		if err != nil {
+			return fmt.Errorf("resolve management group %q: %w", g, err)
+		}
+		for _, id := range ids {
+			f.groupSubs[strings.ToLower(id)] = true
+		}
+	}
+	return nil
+}
+
+// match reports whether r passes the filters.
+func (f filters) match(r cache.Resource) bool {
+	if len(f.groups) > 0 && !f.groupSubs[strings.ToLower(r.SubscriptionID)] {
+		return false
+	}
+	return matchAny(f.subs, r.SubscriptionID, r.SubscriptionName) &&
+		matchAny(f.tenants, r.TenantID, r.TenantName, r.TenantDomain) &&
+		matchAny(f.types, "", r.Type) &&
+		matchAny(f.locations, "", r.Location) &&
+		matchTags(f.tags, r.Tags)
+}
+
+// matchTags reports whether tags has, for each key named in filters, one of
+// the values given for it. Keys and values ignore case.
+func matchTags(filters []string, tags map[string]string) bool {
+	wanted := map[string]bool{}
+	found := map[string]bool{}
+	for _, f := range filters {
+		key, value, hasValue := strings.Cut(f, "=")
+		key = strings.ToLower(key)
+		wanted[key] = true
+		for k, v := range tags {
+			if strings.EqualFold(k, key) && (!hasValue || strings.EqualFold(v, value)) {
+				found[key] = true
+			}
+		}
+	}
+	return len(found) == len(wanted)
+}
+
+// matchAny reports whether one of values equals id or is contained in one of
+// names, ignoring case. No values match everything.
+func matchAny(values []string, id string, names ...string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(id, v) {
+			return true
+		}
+		for _, name := range names {
+			if name != "" && strings.Contains(strings.ToLower(name), strings.ToLower(v)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apply returns the resources that pass the filters.
+func (f filters) apply(rs []cache.Resource) []cache.Resource {
+	if f.empty() {
+		return rs
+	}
+	var out []cache.Resource
+	for _, r := range rs {
+		if f.match(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package fzfui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chege/azfind/internal/cache"
+)
+
+func TestParseArgs(t *testing.T) {
+	terms, f := parseArgs([]string{"api", "SUB:Prod", "sub:", "sub:0000-1111", "tenant:contoso", "mg:corp", "type:keyvault", "loc:westeurope", "tag:owner=team-x"})
+	if want := []string{"api", "sub:"}; !reflect.DeepEqual(terms, want) {
+		t.Fatalf("terms = %q, want %q", terms, want)
+	}
+	if want := []string{"Prod", "0000-1111"}; !reflect.DeepEqual(f.subs, want) {
+		t.Fatalf("subs = %q, want %q", f.subs, want)
+	}
+	if want := []string{"contoso"}; !reflect.DeepEqual(f.tenants, want) {
+		t.Fatalf("tenants = %q, want %q", f.tenants, want)
+	}
+	if want := []string{"corp"}; !reflect.DeepEqual(f.groups, want) {
+		t.Fatalf("groups = %q, want %q", f.groups, want)
+	}
+	if f.types[0] != "keyvault" || f.locations[0] != "westeurope" || f.tags[0] != "owner=team-x" {
+		t.Fatalf("unexpected type, location or tag filters: %+v", f)
+	}
+}
+
+func TestFiltersApply(t *testing.T) {
+	rs := []cache.Resource{
+		{Name: "a", SubscriptionID: "0000-1111", SubscriptionName: "Production", TenantID: "t1", TenantDomain: "contoso.onmicrosoft.com",
+			Type: "Microsoft.KeyVault/vaults", Location: "westeurope", Tags: map[string]string{"Owner": "team-x", "env": "prod"}},
+		{Name: "b", SubscriptionID: "2222-3333", SubscriptionName: "Development", TenantID: "t2", TenantName: "Fabrikam",
+			Type: "Microsoft.Web/sites", Location: "northeurope", Tags: map[string]string{"owner": "team-y"}},
+		{Name: "c", SubscriptionID: "4444-5555"},
+	}
+	names := func(rs []cache.Resource) []string {
+		var out []string
+		for _, r := range rs {
+			out = append(out, r.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		f    filters
+		want []string
+	}{
+		{filters{}, []string{"a", "b", "c"}},
+		{filters{subs: []string{"prod"}}, []string{"a"}},
+		{filters{subs: []string{"4444-5555"}}, []string{"c"}},
+		{filters{subs: []string{"4444"}}, nil},
+		{filters{subs: []string{"dev", "PRODUCTION"}}, []string{"a", "b"}},
+		{filters{tenants: []string{"contoso"}}, []string{"a"}},
+		{filters{tenants: []string{"T2"}}, []string{"b"}},
+		{filters{subs: []string{"prod"}, tenants: []string{"fabrikam"}}, nil},
+		{filters{groups: []string{"corp"}, groupSubs: map[string]bool{"2222-3333": true, "4444-5555": true}}, []string{"b", "c"}},
+		{filters{groups: []string{"corp"}}, nil},
+		{filters{types: []string{"keyvault"}}, []string{"a"}},
+		{filters{locations: []string{"europe"}}, []string{"a", "b"}},
+		{filters{tags: []string{"owner"}}, []string{"a", "b"}},
+		{filters{tags: []string{"owner=TEAM-X", "owner=team-z"}}, []string{"a"}},
+		{filters{tags: []string{"owner=team-y", "env"}}, nil},
+		{filters{types: []string{"keyvault"}, locations: []string{"westeurope"}, tags: []string{"owner=team-x"}}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		if got := names(tt.f.apply(rs)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: got %q, want %q", tt.f, got, tt.want)
+		}
+	}
+}
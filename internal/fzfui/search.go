@@ -1,17 +1,69 @@
 package fzfui
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
-	"runtime"
 	"strings"
+	"time"
 
+	"github.com/chege/azfind/internal/actions"
+	"github.com/chege/azfind/internal/armid"
 	"github.com/chege/azfind/internal/cache"
+	"github.com/chege/azfind/internal/display"
+	"github.com/chege/azfind/internal/picker"
+	"github.com/chege/azfind/internal/portal"
+	"github.com/chege/azfind/internal/syncer"
+	"github.com/chege/azfind/internal/tui"
 )
 
-// RunSearch performs optional prefiltering, launches fzf, and opens the selected resource.
-func RunSearch(ctx context.Context, args []string) error {
+// SearchOptions controls how RunSearch treats the cache.
+type SearchOptions struct {
+	// MaxAge is how old the cache may get before it is considered stale. Zero disables the check.
+	MaxAge time.Duration
+	// AutoRefresh starts a detached background sync when the cache is stale.
+	AutoRefresh bool
+	// Actions available in the picker. Nil uses the built-in actions.
+	Actions *actions.Registry
+	// MaxTabs caps how many browser tabs one bulk action opens. Zero means no cap.
+	MaxTabs int
+	// DefaultAction runs on direct hits and on enter in the picker. Empty
+	// means actions.OpenPortal.
+	DefaultAction string
+	// UI chooses the picker: "fzf", "builtin", or "auto" (the default) for
+	// fzf when it is on PATH and the builtin picker otherwise.
+	UI string
+	// Preview is passed on to picker.Options.Preview.
+	Preview []string
+	// Layout chooses the picker columns.
+	Layout picker.Layout
+	// PreferTypes ranks resources that share the queried name, see
+	// picker.RankByType.
+	PreferTypes []string
+}
+
+// RunSearch performs optional prefiltering, launches the picker, and opens the selected resource.
+// A full resource ID or portal link opens that resource directly, even if it
+// is not cached. So does an exact name, unless several resources share it.
+// Arguments like "sub:prod", "tenant:contoso", "mg:landing-zones",
+// "type:keyvault", "loc:westeurope" and "tag:owner=team-x" restrict the
+// search to matching subscriptions, tenants, management groups, types,
+// locations and tags.
+// It returns the picker errors, e.g. picker.ErrNoMatch, when nothing was selected.
+func RunSearch(ctx context.Context, args []string, opts SearchOptions) error {
+	reg := opts.Actions
+	if reg == nil {
+		reg = actions.Default(actions.Options{})
+	}
+	defaultAction := opts.DefaultAction
+	if defaultAction == "" {
+		defaultAction = actions.OpenPortal
+	}
+
 	db, err := cache.Open(ctx)
 	if err != nil {
 		return fmt.Errorf("open cache: %w", err)
@@ -22,16 +74,41 @@ func RunSearch(ctx context.Context, args []string) error {
 		}
 	}()
 
+	// Checked before anything is opened, so a stale cache is refreshed even
+	// when the picker is skipped.
+	stale := staleWarning(ctx, db, opts)
+	open := func(action string, resources []cache.Resource) error {
+		if stale != "" {
+			_, _ = fmt.Fprintf(os.Stderr, "warning: %s\n", stale)
+		}
+		return runAction(ctx, db, reg, action, resources, opts)
+	}
+
+	args, filter := parseArgs(args)
+	if err := filter.resolve(ctx, db); err != nil {
+		return err
+	}
+
 	if len(args) == 1 {
-		exactResource, err := db.FindResourceByExactName(ctx, args[0])
+		r, action, err := lookupLink(ctx, db, reg, args[0], defaultAction)
 		if err != nil {
-			return fmt.Errorf("find resource by exact name: %w", err)
+			return err
 		}
-		if exactResource != nil {
-			if err := openResource(*exactResource); err != nil {
-				return fmt.Errorf("failed to open resource: %w", err)
-			}
-			return nil
+		if r != nil && filter.match(*r) {
+			return open(action, []cache.Resource{*r})
+		}
+
+		exact, err := db.FindResourcesByExactName(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("find resources by exact name: %w", err)
+		}
+		switch exact = filter.apply(exact); {
+		case len(exact) == 1:
+			return open(defaultAction, exact)
+		case len(exact) > 1:
+			// Shown without a query so the ranking is kept.
+			header := fmt.Sprintf("%d resources named %s", len(exact), args[0])
+			return choose(ctx, db, reg, defaultAction, picker.RankByType(exact, opts.PreferTypes), "", joinLines(header, stale), opts)
 		}
 	}
 
@@ -68,46 +145,301 @@ func RunSearch(ctx context.Context, args []string) error {
 		}
 	}
 
+	resources = filter.apply(resources)
 	if len(resources) == 0 {
-		fmt.Println("No cached resources match the query. Run `azf sync` to refresh.")
-		return nil
+		return picker.ErrNoMatch
+	}
+	if query != "" {
+		resources, err = withChildren(ctx, db, resources)
+		if err != nil {
+			return err
+		}
 	}
 
 	// If only one result remains → open directly
 	if len(resources) == 1 {
-		if err := openResource(resources[0]); err != nil {
-			return fmt.Errorf("failed to open resource: %w", err)
+		return open(defaultAction, resources[:1])
+	}
+
+	return choose(ctx, db, reg, defaultAction, resources, query, stale, opts)
+}
+
+// RunPick shows the resources with the given IDs in the picker, e.g. the
+// rows of a live query, and runs the chosen action. Resources that are not
+// cached are described from their ID; invalid IDs are skipped.
+func RunPick(ctx context.Context, ids []string, header string, opts SearchOptions) error {
+	reg := opts.Actions
+	if reg == nil {
+		reg = actions.Default(actions.Options{})
+	}
+	defaultAction := opts.DefaultAction
+	if defaultAction == "" {
+		defaultAction = actions.OpenPortal
+	}
+
+	db, err := cache.Open(ctx)
+	if err != nil {
+		return fmt.Errorf("open cache: %w", err)
+	}
+	defer func() {
+		if cerr := db.Close(); cerr != nil {
+			fmt.Printf("warning: failed to close cache db: %v\n", cerr)
+		}
+	}()
+
+	stale := staleWarning(ctx, db, opts)
+
+	var resources []cache.Resource
+	for _, id := range ids {
+		r, err := db.LookupID(ctx, id)
+		switch {
+		case errors.Is(err, armid.ErrInvalid):
+			continue
+		case err != nil:
+			return fmt.Errorf("look up resource id: %w", err)
 		}
-		return nil
+		resources = append(resources, *r)
 	}
+	if len(resources) == 0 {
+		return picker.ErrNoMatch
+	}
+	return choose(ctx, db, reg, defaultAction, resources, "", joinLines(header, stale), opts)
+}
 
-	// Run fzf selector
-	selected, err := SelectResource(resources, query)
+// choose shows resources in the picker, with msg above the hints, and runs
+// the chosen action.
+func choose(ctx context.Context, db *cache.DB, reg *actions.Registry, defaultAction string, resources []cache.Resource, query, msg string, opts SearchOptions) error {
+	p, err := newPicker(opts.UI)
 	if err != nil {
 		return err
 	}
-	if selected == nil {
-		// user cancelled
-		return nil
+	selected, err := pick(ctx, db, p, resources, picker.Options{
+		Query:         query,
+		Header:        msg,
+		Actions:       reg,
+		DefaultAction: defaultAction,
+		Layout:        opts.Layout,
+		Preview:       opts.Preview,
+	})
+	if err != nil {
+		return err
+	}
+	return runAction(ctx, db, reg, selected.Action, selected.Resources, opts)
+}
+
+// lookupLink resolves a resource ID or portal link given instead of a query.
+// It returns nil for other arguments, including partial IDs, which are
+// searched for instead. The blade of a link replaces the default open action,
+// so reopening lands on the same page.
+func lookupLink(ctx context.Context, db *cache.DB, reg *actions.Registry, arg, defaultAction string) (*cache.Resource, string, error) {
+	id, tenant, blade := arg, "", ""
+	switch {
+	case portal.IsURL(arg):
+		link, err := portal.ParseURL(arg)
+		if err != nil {
+			return nil, "", err
+		}
+		// Prefer the longest ID that is cached, so a blade such as
+		// "settings/configuration" is not taken for a child resource.
+		for _, l := range link.Splits() {
+			cached, err := db.FindResourceByID(ctx, l.ID)
+			if err != nil {
+				return nil, "", fmt.Errorf("look up resource id: %w", err)
+			}
+			if cached != nil {
+				link = l
+				break
+			}
+		}
+		id, tenant, blade = link.ID, link.Tenant, link.Blade
+	case !strings.HasPrefix(arg, "/"):
+		return nil, "", nil
+	}
+
+	r, err := db.LookupID(ctx, id)
+	switch {
+	case errors.Is(err, armid.ErrInvalid):
+		return nil, "", nil
+	case err != nil:
+		return nil, "", fmt.Errorf("look up resource id: %w", err)
+	}
+
+	if r.TenantID == "" && r.TenantDomain == "" {
+		if strings.Contains(tenant, ".") {
+			r.TenantDomain = tenant
+		} else {
+			r.TenantID = tenant
+		}
+	}
+	if blade != "" && defaultAction == actions.OpenPortal {
+		a := reg.OpenBlade(blade)
+		if _, ok := reg.Get(a.Name); !ok {
+			reg.Register(a)
+		}
+		defaultAction = a.Name
+	}
+	return r, defaultAction, nil
+}
+
+// pick runs p on resources until the user chooses an action. Asking for the
+// children of the selection shows them in a new run.
+func pick(ctx context.Context, db *cache.DB, p picker.Picker, resources []cache.Resource, opts picker.Options) (*picker.Selection, error) {
+	for {
+		selected, err := p.Select(resources, opts)
+		if err != nil || selected.Action != picker.ChildrenAction {
+			return selected, err
+		}
+
+		ids := make([]string, len(selected.Resources))
+		for i, r := range selected.Resources {
+			ids[i] = r.ID
+		}
+		children, err := db.FindChildren(ctx, ids...)
+		if err != nil {
+			return nil, fmt.Errorf("find children: %w", err)
+		}
+		if len(children) == 0 {
+			opts.Header = "no cached children of " + picker.MenuPrompt(selected.Resources)
+			continue
+		}
+		resources, opts.Query = children, ""
+		opts.Header = "children of " + picker.MenuPrompt(selected.Resources)
+	}
+}
+
+// maxChildParents caps the matches whose children a search adds; broad
+// queries have enough results already.
+const maxChildParents = 100
+
+// withChildren adds the children of the matched resources, so searching for
+// a SQL server also lists its databases.
+func withChildren(ctx context.Context, db *cache.DB, resources []cache.Resource) ([]cache.Resource, error) {
+	if len(resources) > maxChildParents {
+		return resources, nil
+	}
+	seen := make(map[string]bool, len(resources))
+	ids := make([]string, len(resources))
+	for i, r := range resources {
+		seen[strings.ToLower(r.ID)] = true
+		ids[i] = r.ID
+	}
+	children, err := db.FindChildren(ctx, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("find children: %w", err)
+	}
+	for _, c := range children {
+		if !seen[strings.ToLower(c.ID)] {
+			seen[strings.ToLower(c.ID)] = true
+			resources = append(resources, c)
+		}
+	}
+	return resources, nil
+}
+
+// newPicker returns the picker configured by ui.
+func newPicker(ui string) (picker.Picker, error) {
+	switch ui {
+	case "", "auto":
+		if _, err := exec.LookPath("fzf"); err != nil {
+			return tui.Picker{}, nil
+		}
+		return FZF{}, nil
+	case "fzf":
+		return FZF{}, nil
+	case "builtin":
+		return tui.Picker{}, nil
+	default:
+		return nil, fmt.Errorf("unknown ui %q (want auto, fzf or builtin)", ui)
+	}
+}
+
+// runAction runs the registry action called name on rs and records it in the
+// open history shown by the preview. Actions that open a browser tab per
+// resource ask for confirmation first when several resources are selected,
+// and open at most opts.MaxTabs of them.
+func runAction(ctx context.Context, db *cache.DB, reg *actions.Registry, name string, rs []cache.Resource, opts SearchOptions) error {
+	a, ok := reg.Get(name)
+	if !ok {
+		return fmt.Errorf("unknown action %q", name)
+	}
+
+	if a.OpensBrowser && len(rs) > 1 {
+		prompt := fmt.Sprintf("Open %d browser tabs?", len(rs))
+		if opts.MaxTabs > 0 && len(rs) > opts.MaxTabs {
+			prompt = fmt.Sprintf("%d resources selected; open the first %d?", len(rs), opts.MaxTabs)
+			rs = rs[:opts.MaxTabs]
+		}
+		if !confirm(os.Stdin, os.Stdout, prompt) {
+			return nil
+		}
+	}
+
+	if err := actions.RunOn(ctx, a, rs); err != nil {
+		return err
 	}
 
-	if err := openResource(*selected); err != nil {
-		return fmt.Errorf("failed to open resource: %w", err)
+	ids := make([]string, len(rs))
+	for i, r := range rs {
+		ids[i] = r.ID
+	}
+	if err := db.RecordOpens(ctx, name, time.Now(), ids...); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: failed to record history: %v\n", err)
 	}
 	return nil
 }
 
-func openResource(r cache.Resource) error {
-	url := fmt.Sprintf("https://portal.azure.com/#@%s/resource%s", r.TenantID, r.ID)
+// confirm asks a yes/no question and defaults to no.
+func confirm(in io.Reader, out io.Writer, prompt string) bool {
+	_, _ = fmt.Fprintf(out, "%s [y/N] ", prompt)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// joinLines joins the non-empty lines of a picker header.
+func joinLines(lines ...string) string {
+	var out []string
+	for _, l := range lines {
+		if l != "" {
+			out = append(out, l)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// staleWarning returns a warning when the cache is older than opts.MaxAge,
+// starting a background refresh if configured. The current search always runs
+// against the existing cache.
+func staleWarning(ctx context.Context, db *cache.DB, opts SearchOptions) string {
+	if opts.MaxAge <= 0 {
+		return ""
+	}
+
+	last, err := db.LastUpdated(ctx)
+	if err != nil || last.IsZero() {
+		return ""
+	}
+	age := time.Since(last)
+	if age <= opts.MaxAge {
+		return ""
+	}
 
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
-	case "darwin":
-		cmd = exec.Command("open", url)
-	case "linux":
-		cmd = exec.Command("xdg-open", url)
+	msg := fmt.Sprintf("cache is %s old", display.Age(age))
+	if !opts.AutoRefresh {
+		return msg + " - run `azf sync` to refresh"
+	}
+
+	started, err := syncer.StartBackground()
+	switch {
+	case err != nil:
+		return fmt.Sprintf("%s - background sync failed: %v", msg, err)
+	case started:
+		return msg + " - refreshing in background"
 	default:
-		return fmt.Errorf("unsupported platform")
+		return msg + " - sync already running"
 	}
-	return cmd.Start()
 }
@@ -0,0 +1,97 @@
+package fzfui
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chege/azfind/internal/actions"
+	"github.com/chege/azfind/internal/cache"
+	"github.com/chege/azfind/internal/portal"
+)
+
+func TestWithChildren(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	db, err := cache.Open(ctx)
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	const server = "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Sql/servers/sql-prod"
+	all := []cache.Resource{
+		{ID: server, Name: "sql-prod", Type: "Microsoft.Sql/servers", SubscriptionID: "sub1"},
+		{ID: server + "/databases/orders", Name: "orders", Type: "Microsoft.Sql/servers/databases", SubscriptionID: "sub1"},
+		{ID: server + "/databases/sql-prod-audit", Name: "sql-prod-audit", Type: "Microsoft.Sql/servers/databases", SubscriptionID: "sub1"},
+	}
+	if err := db.InsertResources(ctx, all); err != nil {
+		t.Fatalf("insert resources: %v", err)
+	}
+
+	matched, err := db.FindResourcesByNamePrefix(ctx, "sql-prod")
+	if err != nil {
+		t.Fatalf("find resources: %v", err)
+	}
+	got, err := withChildren(ctx, db, matched)
+	if err != nil {
+		t.Fatalf("with children: %v", err)
+	}
+	names := map[string]int{}
+	for _, r := range got {
+		names[r.Name]++
+	}
+	if len(got) != 3 || names["orders"] != 1 || names["sql-prod-audit"] != 1 {
+		t.Fatalf("expected the server and both databases once, got %+v", got)
+	}
+}
+
+func TestLookupLink(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	db, err := cache.Open(ctx)
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	const kv = "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv-prod"
+	if err := db.InsertResources(ctx, []cache.Resource{{ID: kv, Name: "kv-prod", Type: "Microsoft.KeyVault/vaults", SubscriptionID: "sub1", TenantID: "tenant1"}}); err != nil {
+		t.Fatalf("insert resources: %v", err)
+	}
+	reg := actions.Default(actions.Options{})
+
+	r, action, err := lookupLink(ctx, db, reg, "<https://portal.azure.com/#@contoso.com/resource"+kv+"/users>", actions.OpenPortal)
+	if err != nil || r == nil || r.Name != "kv-prod" || r.TenantID != "tenant1" {
+		t.Fatalf("expected the cached kv-prod, got %+v, %v", r, err)
+	}
+	if _, ok := reg.Get(action); action != "open-users" || !ok {
+		t.Fatalf("expected the link's blade as a registered action, got %q", action)
+	}
+
+	// A blade of two segments parses as a child resource too; the cached
+	// resource decides.
+	r, action, err = lookupLink(ctx, db, reg, "https://portal.azure.com/#@contoso.com/resource"+kv+"/settings/configuration", actions.OpenPortal)
+	if err != nil || r == nil || r.Name != "kv-prod" {
+		t.Fatalf("expected the cached kv-prod for a two-segment blade, got %+v, %v", r, err)
+	}
+	if _, ok := reg.Get(action); !ok || action != reg.OpenBlade("settings/configuration").Name {
+		t.Fatalf("expected the two-segment blade as a registered action, got %q", action)
+	}
+
+	r, action, err = lookupLink(ctx, db, reg, "https://portal.azure.com/#@contoso.com/resource/subscriptions/sub2/resourceGroups/rg2", actions.CopyID)
+	if err != nil || r == nil || r.ResourceGroup != "rg2" || r.TenantDomain != "contoso.com" || action != actions.CopyID {
+		t.Fatalf("expected an uncached rg2 in contoso.com, got %+v, %q, %v", r, action, err)
+	}
+
+	for _, arg := range []string{"kv-prod", "/resourceGroups/rg1"} {
+		if r, _, err := lookupLink(ctx, db, reg, arg, actions.OpenPortal); r != nil || err != nil {
+			t.Fatalf("%s: expected a search, got %+v, %v", arg, r, err)
+		}
+	}
+	if _, _, err := lookupLink(ctx, db, reg, "https://portal.azure.com/#home", actions.OpenPortal); !errors.Is(err, portal.ErrNotResourceLink) {
+		t.Fatalf("expected ErrNotResourceLink, got %v", err)
+	}
+}
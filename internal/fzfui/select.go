@@ -2,76 +2,52 @@ package fzfui
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 
+	"github.com/chege/azfind/internal/actions"
 	"github.com/chege/azfind/internal/cache"
+	"github.com/chege/azfind/internal/picker"
 )
 
-// trunc shortens a string to maxRunes characters, adding "..." if needed.
-// It operates on runes to avoid breaking multi-byte characters mid-codepoint.
-func trunc(s string, maxRunes int) string {
-	if maxRunes <= 0 {
-		return ""
-	}
+// previewPercent is the share of the terminal width taken by the preview.
+const previewPercent = 40
 
-	runes := []rune(s)
-	if len(runes) <= maxRunes {
-		return s
-	}
-	if maxRunes <= 3 {
-		return string(runes[:maxRunes])
-	}
-	return string(runes[:maxRunes-3]) + "..."
-}
+// FZF is the picker backed by the external fzf binary.
+type FZF struct{}
 
-// shortType returns the last segment of the resource type, e.g. "microsoft.app/containerapps" → "containerapps".
-func shortType(full string) string {
-	if full == "" {
-		return ""
-	}
-	if i := strings.LastIndex(full, "/"); i >= 0 && i+1 < len(full) {
-		return full[i+1:]
-	}
-	return full
+// Select implements picker.Picker.
+func (FZF) Select(resources []cache.Resource, opts picker.Options) (*picker.Selection, error) {
+	return SelectResource(resources, opts)
 }
 
-// SelectResource runs fzf on the given resources and returns the selected one.
-func SelectResource(resources []cache.Resource, initialQuery string) (*cache.Resource, error) {
+// SelectResource runs fzf on the given resources and returns the selected ones
+// and the chosen action. Tab marks several resources. Enter selects the
+// default action; key bindings of the registry pick their own action and
+// ctrl-a shows an action menu.
+func SelectResource(resources []cache.Resource, opts picker.Options) (*picker.Selection, error) {
 	if len(resources) == 0 {
-		return nil, nil
+		return nil, picker.ErrNoMatch
 	}
 
-	// Fixed column widths for visible part; everything else goes into hidden fields.
-	const (
-		nameWidth = 40
-		typeWidth = 24
-		rgWidth   = 30
-	)
+	labels := opts.Layout.Lines(resources, picker.ListWidth(picker.TerminalWidth(), previewPercent), false)
 
 	var buf bytes.Buffer
-	for _, r := range resources {
-		name := trunc(r.Name, nameWidth)
-		typeShort := trunc(shortType(r.Type), typeWidth)
-		rg := trunc(r.ResourceGroup, rgWidth)
-
-		visible := fmt.Sprintf("%-*s | %-*s | %-*s",
-			nameWidth, name,
-			typeWidth, typeShort,
-			rgWidth, rg,
-		)
-
+	for i, r := range resources {
 		// Hidden full fields after the first tab:
-		// {2}=Name, {3}=Type, {4}=ResourceGroup, {5}=SubscriptionID, {6}=Location, {7}=ID
-		line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s",
-			visible,
+		// {2}=Name, {3}=Type, {4}=ResourceGroup, {5}=SubscriptionID, {6}=Location, {7}=ID, {8}=Synced
+		line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
+			labels[i],
 			r.Name,
 			r.Type,
 			r.ResourceGroup,
 			r.SubscriptionID,
 			r.Location,
 			r.ID,
+			picker.Freshness(r.UpdatedAt),
 		)
 
 		buf.WriteString(line)
@@ -79,27 +55,188 @@ func SelectResource(resources []cache.Resource, initialQuery string) (*cache.Res
 	}
 
 	// Create fzf command: show only column 1, but search across all fields.
-	cmd := exec.Command("fzf",
+	args := []string{
 		"--ansi",
 		"--delimiter", "\t",
 		"--with-nth", "1",
 		"--nth", "1..7",
-		"--preview", "echo -e \"Type:            {3}\\nName:            {2}\\nSubscription:    {5}\\nResource group:  {4}\\nLocation:        {6}\\nID:              {7}\"",
-		"--preview-window", "right:40%",
-		"--query="+initialQuery,
+		"--preview", previewCommand(opts.Preview),
+		"--preview-window", fmt.Sprintf("right:%d%%", previewPercent),
+		"--multi",
+		"--query=" + opts.Query,
+	}
+	reg := opts.Actions
+	defaultAction := opts.DefaultAction
+	if defaultAction == "" {
+		defaultAction = actions.OpenPortal
+	}
+
+	args = append(args, "--expect", strings.Join(picker.Keys(reg), ","))
+	args = append(args, "--header", picker.Header(opts.Header, defaultAction, reg))
+
+	cmd := exec.Command("fzf", args...)
+	cmd.Stdin = &buf
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fzfError(err)
+	}
+
+	// With --expect, fzf prints the pressed key (empty for enter) on the first
+	// line, followed by one line per selected entry.
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	key := lines[0]
+
+	var picked []cache.Resource
+	for _, line := range lines[1:] {
+		if r := resourceFromLine(resources, line); r != nil {
+			picked = append(picked, *r)
+		}
+	}
+	if len(picked) == 0 {
+		return nil, picker.ErrNoMatch
+	}
+
+	action, err := picker.ChooseAction(reg, key, defaultAction, picked, selectAction)
+	if err != nil {
+		return nil, err
+	}
+
+	return &picker.Selection{Resources: picked, Action: action}, nil
+}
+
+// List implements picker.Picker.
+func (FZF) List(lines []string, opts picker.ListOptions) (*picker.Choice, error) {
+	if len(lines) == 0 {
+		return nil, picker.ErrNoMatch
+	}
+
+	var buf bytes.Buffer
+	for i, line := range lines {
+		fmt.Fprintf(&buf, "%d\t%s\n", i, line)
+	}
+
+	args := []string{
+		"--ansi",
+		"--delimiter", "\t",
+		"--with-nth", "2..",
+		"--prompt", opts.Prompt,
+		"--reverse",
+	}
+	if opts.Header != "" {
+		args = append(args, "--header", opts.Header)
+	}
+	if len(opts.Keys) > 0 {
+		args = append(args, "--expect", strings.Join(opts.Keys, ","))
+	}
+
+	cmd := exec.Command("fzf", args...)
+	cmd.Stdin = &buf
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fzfError(err)
+	}
+
+	// With --expect the pressed key comes first, then the chosen line.
+	result := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	key := ""
+	if len(opts.Keys) > 0 {
+		key, result = result[0], result[1:]
+	}
+	if len(result) == 0 {
+		return nil, picker.ErrNoMatch
+	}
+	field, _, _ := strings.Cut(result[0], "\t")
+	i, err := strconv.Atoi(field)
+	if err != nil || i < 0 || i >= len(lines) {
+		return nil, fmt.Errorf("unexpected fzf output %q", result[0])
+	}
+	return &picker.Choice{Index: i, Key: key}, nil
+}
+
+// previewCommand returns fzf's --preview command: cmd with the resource ID
+// field appended, or a summary of the cached fields if cmd is empty.
+func previewCommand(cmd []string) string {
+	if len(cmd) == 0 {
+		return "echo -e \"Type:            {3}\\nName:            {2}\\nSubscription:    {5}\\nResource group:  {4}\\nLocation:        {6}\\nID:              {7}\\nSynced:          {8}\""
+	}
+	quoted := make([]string, len(cmd))
+	for i, arg := range cmd {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ") + " {7}"
+}
+
+// selectAction shows the given actions in fzf and returns the chosen name.
+func selectAction(list []actions.Action, prompt string) (string, error) {
+	var buf bytes.Buffer
+	for _, a := range list {
+		desc := a.Description
+		if a.Key != "" {
+			desc += " (" + a.Key + ")"
+		}
+		fmt.Fprintf(&buf, "%s\t%-20s %s\n", a.Name, a.Name, desc)
+	}
+
+	cmd := exec.Command("fzf",
+		"--delimiter", "\t",
+		"--with-nth", "2..",
+		"--prompt", prompt+" > ",
+		"--height", "40%",
+		"--reverse",
 	)
 	cmd.Stdin = &buf
 
 	out, err := cmd.Output()
 	if err != nil {
-		// User pressed ESC or CTRL-C → no selection
-		return nil, nil
+		err = fzfError(err)
+		if errors.Is(err, picker.ErrNoMatch) {
+			// Accepting an empty action list just closes the menu.
+			err = picker.ErrCancelled
+		}
+		return "", err
+	}
+	name, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\t")
+	return name, nil
+}
+
+// fzfError maps a failed fzf run to the picker errors using fzf's exit codes:
+// 1 means no match, 130 means the user pressed esc or ctrl-c and 2 is an
+// error reported on stderr, such as a bad option or an unusable terminal,
+// which makes the picker unavailable like a missing fzf.
+func fzfError(err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%w: fzf not found on PATH", picker.ErrPickerUnavailable)
 	}
 
-	selection := strings.TrimSpace(string(out))
-	parts := strings.Split(selection, "\t")
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return fmt.Errorf("run fzf: %w", err)
+	}
+	switch exitErr.ExitCode() {
+	case 1:
+		return picker.ErrNoMatch
+	case 130:
+		return picker.ErrCancelled
+	case 2:
+		if msg := strings.TrimSpace(string(exitErr.Stderr)); msg != "" {
+			return fmt.Errorf("%w: fzf: %s", picker.ErrPickerUnavailable, msg)
+		}
+		return fmt.Errorf("%w: run fzf: %w", picker.ErrPickerUnavailable, err)
+	}
+
+	if msg := strings.TrimSpace(string(exitErr.Stderr)); msg != "" {
+		return fmt.Errorf("fzf: %s", msg)
+	}
+	return fmt.Errorf("run fzf: %w", err)
+}
+
+// resourceFromLine maps an fzf output line back to its resource.
+func resourceFromLine(resources []cache.Resource, line string) *cache.Resource {
+	parts := strings.Split(line, "\t")
 	if len(parts) < 7 {
-		return nil, nil
+		return nil
 	}
 
 	// Hidden full values
@@ -111,15 +248,15 @@ func SelectResource(resources []cache.Resource, initialQuery string) (*cache.Res
 
 	// Prefer matching by ID (should be unique); fall back to name/type/rg/sub if needed.
 	for _, r := range resources {
-		if r.ID == id && r.SubscriptionID == sub {
-			return &r, nil
+		if strings.EqualFold(r.ID, id) && strings.EqualFold(r.SubscriptionID, sub) {
+			return &r
 		}
 	}
 	for _, r := range resources {
 		if r.Name == nameFull && r.Type == typeFull && r.ResourceGroup == rgFull && r.SubscriptionID == sub {
-			return &r, nil
+			return &r
 		}
 	}
 
-	return nil, nil
+	return nil
 }
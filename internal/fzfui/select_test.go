@@ -0,0 +1,57 @@
+package fzfui
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/chege/azfind/internal/picker"
+)
+
+func TestFzfError(t *testing.T) {
+	run := func(script string) error {
+		_, err := exec.Command("sh", "-c", script).Output()
+		return err
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"no match", run("exit 1"), picker.ErrNoMatch},
+		{"interrupted", run("exit 130"), picker.ErrCancelled},
+		{"not installed", &exec.Error{Name: "fzf", Err: exec.ErrNotFound}, picker.ErrPickerUnavailable},
+	}
+	for _, tt := range tests {
+		if got := fzfError(tt.err); !errors.Is(got, tt.want) {
+			t.Errorf("%s: fzfError() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	got := fzfError(run("echo 'unknown option: --bogus' >&2; exit 2"))
+	if !errors.Is(got, picker.ErrPickerUnavailable) || !strings.Contains(got.Error(), "unknown option: --bogus") {
+		t.Errorf("exit 2: fzfError() = %v, want ErrPickerUnavailable with fzf's message", got)
+	}
+
+	got = fzfError(run("echo 'killed' >&2; exit 137"))
+	for _, want := range []error{picker.ErrNoMatch, picker.ErrCancelled, picker.ErrPickerUnavailable} {
+		if errors.Is(got, want) {
+			t.Errorf("exit 137: fzfError() = %v, should not be %v", got, want)
+		}
+	}
+	if !strings.Contains(got.Error(), "killed") {
+		t.Errorf("exit 137: fzfError() = %v, want the message", got)
+	}
+}
+
+func TestPreviewCommand(t *testing.T) {
+	got := previewCommand([]string{"/opt/it's/azf", "__preview"})
+	if want := `'/opt/it'\''s/azf' '__preview' {7}`; got != want {
+		t.Fatalf("previewCommand() = %q, want %q", got, want)
+	}
+	if got := previewCommand(nil); !strings.HasPrefix(got, "echo -e") {
+		t.Fatalf("expected the field summary without a command, got %q", got)
+	}
+}
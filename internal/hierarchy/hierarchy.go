@@ -0,0 +1,249 @@
+// Package hierarchy arranges cached subscriptions under their management
+// groups, and resource groups under their subscriptions.
+package hierarchy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/chege/azfind/internal/cache"
+)
+
+// Kinds of nodes.
+const (
+	KindManagementGroup = "managementGroup"
+	KindSubscription    = "subscription"
+	KindResourceGroup   = "resourceGroup"
+)
+
+// Node is a management group, a subscription or a resource group.
+type Node struct {
+	Kind string
+	// ID is the management group name, the subscription ID or the resource
+	// group name.
+	ID   string
+	Name string
+	// SubscriptionID is set on resource groups.
+	SubscriptionID string
+	// TenantID and TenantDomain locate the node in the portal. Management
+	// groups take them from their first subscription.
+	TenantID     string
+	TenantDomain string
+	// Resources counts the cached resources of the node and its descendants.
+	Resources int
+	// Children are the groups and subscriptions of a management group.
+	// Resource groups are loaded on demand with ResourceGroups.
+	Children []*Node
+}
+
+// Label returns the name, or the ID if the name is unknown.
+func (n *Node) Label() string {
+	if n.Name != "" {
+		return n.Name
+	}
+	return n.ID
+}
+
+// ResourceID returns the Azure resource ID of the node, e.g.
+// "/subscriptions/<id>/resourceGroups/<name>".
+func (n *Node) ResourceID() string {
+	switch n.Kind {
+	case KindManagementGroup:
+		return "/providers/Microsoft.Management/managementGroups/" + n.ID
+	case KindResourceGroup:
+		return "/subscriptions/" + n.SubscriptionID + "/resourceGroups/" + n.ID
+	default:
+		return "/subscriptions/" + n.ID
+	}
+}
+
+// Resource describes the node as a cache.Resource so resource actions, such
+// as opening the portal, can run on it.
+func (n *Node) Resource() cache.Resource {
+	r := cache.Resource{
+		ID:           n.ResourceID(),
+		Name:         n.Label(),
+		TenantID:     n.TenantID,
+		TenantDomain: n.TenantDomain,
+	}
+	switch n.Kind {
+	case KindManagementGroup:
+		r.Type = "Microsoft.Management/managementGroups"
+	case KindSubscription:
+		r.Type = "Microsoft.Resources/subscriptions"
+		r.SubscriptionID = n.ID
+	case KindResourceGroup:
+		r.Type = "Microsoft.Resources/resourceGroups"
+		r.SubscriptionID = n.SubscriptionID
+		r.ResourceGroup = n.ID
+	}
+	return r
+}
+
+// Load builds the tree from the cache.
+func Load(ctx context.Context, db *cache.DB) ([]*Node, error) {
+	groups, err := db.ListManagementGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := db.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := db.ResourceCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tenants, err := db.ListTenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	domains := map[string]string{}
+	for _, t := range tenants {
+		domains[strings.ToLower(t.ID)] = t.DefaultDomain
+	}
+
+	roots := Build(groups, subs, counts)
+	walk(roots, func(n *Node) { n.TenantDomain = domains[strings.ToLower(n.TenantID)] })
+	return roots, nil
+}
+
+// ResourceGroups returns the resource groups of the subscription sub that
+// hold cached resources.
+func ResourceGroups(ctx context.Context, db *cache.DB, sub *Node) ([]*Node, error) {
+	groups, err := db.ResourceGroups(ctx, sub.ID)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*Node, len(groups))
+	for i, g := range groups {
+		nodes[i] = &Node{
+			Kind:           KindResourceGroup,
+			ID:             g.Name,
+			SubscriptionID: sub.ID,
+			TenantID:       sub.TenantID,
+			TenantDomain:   sub.TenantDomain,
+			Resources:      g.Resources,
+		}
+	}
+	return nodes, nil
+}
+
+func walk(nodes []*Node, fn func(*Node)) {
+	for _, n := range nodes {
+		fn(n)
+		walk(n.Children, fn)
+	}
+}
+
+// Build arranges subs under groups and counts their resources from counts,
+// keyed by subscription ID. Groups whose parent is unknown are roots, as are
+// subscriptions outside any known group; those follow the groups.
+func Build(groups []cache.ManagementGroup, subs []cache.Subscription, counts map[string]int) []*Node {
+	byID := make(map[string]*Node, len(groups))
+	for _, g := range groups {
+		byID[strings.ToLower(g.ID)] = &Node{Kind: KindManagementGroup, ID: g.ID, Name: g.DisplayName}
+	}
+
+	var roots []*Node
+	for _, g := range groups {
+		n := byID[strings.ToLower(g.ID)]
+		if parent, ok := byID[strings.ToLower(g.ParentID)]; ok && parent != n {
+			parent.Children = append(parent.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+
+	var loose []*Node
+	for _, s := range subs {
+		n := &Node{Kind: KindSubscription, ID: s.ID, Name: s.DisplayName, TenantID: s.TenantID, Resources: countFor(counts, s.ID)}
+		if parent, ok := byID[strings.ToLower(s.ManagementGroupID)]; ok {
+			parent.Children = append(parent.Children, n)
+		} else {
+			loose = append(loose, n)
+		}
+	}
+
+	for _, n := range roots {
+		total(n)
+	}
+	sortNodes(roots)
+	sortNodes(loose)
+	return append(roots, loose...)
+}
+
+// countFor looks up id in counts, which Resource Graph may have filled with
+// differently cased IDs.
+func countFor(counts map[string]int, id string) int {
+	if n, ok := counts[id]; ok {
+		return n
+	}
+	for k, n := range counts {
+		if strings.EqualFold(k, id) {
+			return n
+		}
+	}
+	return 0
+}
+
+// total sums the resources below n into n and gives management groups the
+// tenant of their first subscription.
+func total(n *Node) int {
+	for _, c := range n.Children {
+		n.Resources += total(c)
+		if n.TenantID == "" {
+			n.TenantID = c.TenantID
+		}
+	}
+	sortNodes(n.Children)
+	return n.Resources
+}
+
+// sortNodes orders management groups before subscriptions, each by label.
+func sortNodes(nodes []*Node) {
+	slices.SortStableFunc(nodes, func(a, b *Node) int {
+		if a.Kind != b.Kind {
+			if a.Kind == KindManagementGroup {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(strings.ToLower(a.Label()), strings.ToLower(b.Label()))
+	})
+}
+
+// Print writes the tree with box-drawing branches and resource counts.
+func Print(w io.Writer, roots []*Node) {
+	for _, n := range roots {
+		_, _ = fmt.Fprintln(w, n.Summary())
+		printChildren(w, n.Children, "")
+	}
+}
+
+func printChildren(w io.Writer, nodes []*Node, indent string) {
+	for i, n := range nodes {
+		branch, next := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, next = "└── ", "    "
+		}
+		_, _ = fmt.Fprintln(w, indent+branch+n.Summary())
+		printChildren(w, n.Children, indent+next)
+	}
+}
+
+// Summary returns the label and resource count of n. Management groups end
+// in a slash like directories.
+func (n *Node) Summary() string {
+	resources := "resources"
+	if n.Resources == 1 {
+		resources = "resource"
+	}
+	if n.Kind == KindManagementGroup {
+		return fmt.Sprintf("%s/ (%d %s)", n.Label(), n.Resources, resources)
+	}
+	return fmt.Sprintf("%s (%d %s)", n.Label(), n.Resources, resources)
+}
@@ -0,0 +1,70 @@
+package hierarchy
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/chege/azfind/internal/cache"
+)
+
+func TestBuildAndPrint(t *testing.T) {
+	groups := []cache.ManagementGroup{
+		{ID: "root", DisplayName: "Tenant Root Group"},
+		{ID: "sandbox", DisplayName: "Sandbox", ParentID: "root"},
+		{ID: "lz", DisplayName: "Landing Zones", ParentID: "root"},
+		{ID: "corp", DisplayName: "Corp", ParentID: "LZ"},
+	}
+	subs := []cache.Subscription{
+		{ID: "s1", DisplayName: "corp-prod", ManagementGroupID: "corp", TenantID: "t1"},
+		{ID: "s2", DisplayName: "corp-dev", ManagementGroupID: "corp"},
+		{ID: "s3", DisplayName: "play", ManagementGroupID: "sandbox"},
+		{ID: "s4", DisplayName: "legacy"},
+	}
+	counts := map[string]int{"s1": 10, "S2": 5, "s3": 1}
+
+	roots := Build(groups, subs, counts)
+	if len(roots) != 2 || roots[0].ID != "root" || roots[1].ID != "s4" {
+		t.Fatalf("expected the root group then the loose subscription, got %+v", roots)
+	}
+	if roots[0].Resources != 16 {
+		t.Fatalf("expected 16 resources under the root, got %d", roots[0].Resources)
+	}
+	if roots[0].TenantID != "t1" {
+		t.Fatalf("expected the root group to take its tenant from corp-prod, got %q", roots[0].TenantID)
+	}
+
+	var buf bytes.Buffer
+	Print(&buf, roots)
+	want := `Tenant Root Group/ (16 resources)
+├── Landing Zones/ (15 resources)
+│   └── Corp/ (15 resources)
+│       ├── corp-dev (5 resources)
+│       └── corp-prod (10 resources)
+└── Sandbox/ (1 resource)
+    └── play (1 resource)
+legacy (0 resources)
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected tree:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestResource(t *testing.T) {
+	tests := []struct {
+		n    Node
+		want string
+	}{
+		{Node{Kind: KindManagementGroup, ID: "corp"}, "/providers/Microsoft.Management/managementGroups/corp"},
+		{Node{Kind: KindSubscription, ID: "s1"}, "/subscriptions/s1"},
+		{Node{Kind: KindResourceGroup, ID: "rg1", SubscriptionID: "s1"}, "/subscriptions/s1/resourceGroups/rg1"},
+	}
+	for _, tt := range tests {
+		r := tt.n.Resource()
+		if r.ID != tt.want {
+			t.Errorf("%s: ID = %q, want %q", tt.n.Kind, r.ID, tt.want)
+		}
+		if tt.n.Kind == KindResourceGroup && (r.SubscriptionID != "s1" || r.ResourceGroup != "rg1") {
+			t.Errorf("expected the resource group fields, got %+v", r)
+		}
+	}
+}
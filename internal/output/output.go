@@ -0,0 +1,115 @@
+// Package output writes tabular results, such as Resource Graph query rows,
+// as an aligned table, JSON or CSV.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rodaine/table"
+)
+
+// Formats understood by Write.
+const (
+	Table = "table"
+	JSON  = "json"
+	CSV   = "csv"
+)
+
+// Formats lists the formats for flag help and completion.
+var Formats = []string{Table, JSON, CSV}
+
+// Validate reports an unknown format.
+func Validate(format string) error {
+	for _, f := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format %q; use %s", format, strings.Join(Formats, ", "))
+}
+
+// Write writes rows with the given columns to w in format. JSON is an array
+// of objects keyed by column; table and CSV cells show nested values as
+// compact JSON.
+func Write(w io.Writer, format string, columns []string, rows [][]any) error {
+	switch format {
+	case Table:
+		tbl := table.New(toAny(columns)...).WithWriter(w)
+		for _, row := range rows {
+			tbl.AddRow(toAny(cells(row))...)
+		}
+		tbl.Print()
+		return nil
+	case JSON:
+		objects := make([]map[string]any, len(rows))
+		for i, row := range rows {
+			objects[i] = make(map[string]any, len(columns))
+			for j, c := range columns {
+				objects[i][c] = row[j]
+			}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(objects); err != nil {
+			return fmt.Errorf("write json: %w", err)
+		}
+		return nil
+	case CSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write(columns)
+		for _, row := range rows {
+			_ = cw.Write(cells(row))
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return fmt.Errorf("write csv: %w", err)
+		}
+		return nil
+	default:
+		return Validate(format)
+	}
+}
+
+// cells formats the values of a row as text.
+func cells(row []any) []string {
+	out := make([]string, len(row))
+	for i, v := range row {
+		out[i] = Cell(v)
+	}
+	return out
+}
+
+// Cell formats a value decoded from JSON as text: strings as they are, nil as
+// "", whole numbers without a fraction and objects and arrays as compact JSON.
+func Cell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+func toAny(s []string) []any {
+	out := make([]any, len(s))
+	for i, v := range s {
+		out[i] = v
+	}
+	return out
+}
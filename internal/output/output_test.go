@@ -0,0 +1,56 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var (
+	columns = []string{"name", "count", "tags"}
+	rows    = [][]any{
+		{"kv-prod", float64(3), map[string]any{"env": "prod"}},
+		{"st, logs", nil, nil},
+	}
+)
+
+func TestWriteTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, Table, columns, rows); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "name") || !strings.Contains(lines[1], `{"env":"prod"}`) {
+		t.Fatalf("unexpected table:\n%s", buf.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, JSON, columns, rows); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"count": 3`) || !strings.Contains(buf.String(), `"tags": null`) {
+		t.Fatalf("unexpected json:\n%s", buf.String())
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, CSV, columns, rows); err != nil {
+		t.Fatal(err)
+	}
+	want := "name,count,tags\nkv-prod,3,\"{\"\"env\"\":\"\"prod\"\"}\"\n\"st, logs\",,\n"
+	if buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("yaml"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+	if err := Write(&bytes.Buffer{}, "yaml", columns, rows); err == nil {
+		t.Fatal("expected Write to reject an unknown format")
+	}
+}
@@ -0,0 +1,292 @@
+package picker
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/chege/azfind/internal/armid"
+	"github.com/chege/azfind/internal/cache"
+	"github.com/chege/azfind/internal/display"
+)
+
+// Column is a resource field shown in the picker list.
+type Column struct {
+	// Field is one of Fields, or "tag:<key>" for the value of a tag.
+	Field string `mapstructure:"field"`
+	// Min is the width the column keeps when the terminal is narrow. Zero
+	// means the shorter of its content and 8.
+	Min int `mapstructure:"min"`
+	// Max caps the width of the column. Zero means no limit.
+	Max int `mapstructure:"max"`
+}
+
+// Fields are the column fields besides tags.
+var Fields = []string{"name", "type", "fullType", "resourceGroup", "subscription", "subscriptionId", "tenant", "tenantId", "location"}
+
+// tagPrefix selects the value of a tag as a column, e.g. "tag:env".
+const tagPrefix = "tag:"
+
+// DefaultColumns is the list layout used when none is configured.
+func DefaultColumns() []Column {
+	return []Column{
+		{Field: "name", Min: 20},
+		{Field: "type", Min: 10, Max: 30},
+		{Field: "resourceGroup", Min: 10, Max: 40},
+	}
+}
+
+// Truncation styles for values wider than their column.
+const (
+	TruncateEnd    = display.TruncateEnd
+	TruncateMiddle = display.TruncateMiddle
+	TruncateStart  = display.TruncateStart
+)
+
+// Layout describes how resources are drawn as list lines.
+type Layout struct {
+	// Columns in display order; empty means DefaultColumns.
+	Columns []Column
+	// Truncate is TruncateEnd (the default), TruncateMiddle or TruncateStart.
+	Truncate string
+	// Colors maps lower-case resource types or provider namespaces, e.g.
+	// "microsoft.web/sites" or "microsoft.storage", to colours. They take
+	// precedence over DefaultColors.
+	Colors map[string]string
+	// NoColor disables colours.
+	NoColor bool
+}
+
+// Validate reports unknown fields, bad widths and unknown colours.
+func (l Layout) Validate() error {
+	for _, c := range l.Columns {
+		if !validField(c.Field) {
+			return fmt.Errorf("unknown column %q (want %s or tag:<key>)", c.Field, strings.Join(Fields, ", "))
+		}
+		if c.Min < 0 || c.Max < 0 || (c.Max > 0 && c.Min > c.Max) {
+			return fmt.Errorf("column %q: invalid widths min %d, max %d", c.Field, c.Min, c.Max)
+		}
+	}
+	switch l.Truncate {
+	case "", TruncateEnd, TruncateMiddle, TruncateStart:
+	default:
+		return fmt.Errorf("unknown truncate style %q (want end, middle or start)", l.Truncate)
+	}
+	for typ, c := range l.Colors {
+		if _, err := sgr(c); err != nil {
+			return fmt.Errorf("colour for %s: %w", typ, err)
+		}
+	}
+	return nil
+}
+
+func validField(f string) bool {
+	if strings.HasPrefix(f, tagPrefix) {
+		return len(f) > len(tagPrefix)
+	}
+	for _, known := range Fields {
+		if f == known {
+			return true
+		}
+	}
+	return false
+}
+
+// value returns the text of field for r.
+func (l Layout) value(r cache.Resource, field string) string {
+	switch field {
+	case "name":
+		return r.Name
+	case "type":
+		return armid.ShortType(r.Type)
+	case "fullType":
+		return r.Type
+	case "resourceGroup":
+		return r.ResourceGroup
+	case "subscription":
+		return r.SubscriptionLabel()
+	case "subscriptionId":
+		return r.SubscriptionID
+	case "tenant":
+		return r.TenantLabel()
+	case "tenantId":
+		return r.TenantID
+	case "location":
+		return r.Location
+	}
+	if key, ok := strings.CutPrefix(field, tagPrefix); ok {
+		return r.Tags[key]
+	}
+	return ""
+}
+
+// columnSep separates the columns of a line.
+const columnSep = " | "
+
+// Lines renders resources as aligned lines at most width cells wide. Columns
+// shrink towards their minimum when the content does not fit, and trailing
+// columns are dropped if even that is too wide. Unless plain is set, the
+// name and type are coloured by resource type.
+func (l Layout) Lines(resources []cache.Resource, width int, plain bool) []string {
+	cols := l.Columns
+	if len(cols) == 0 {
+		cols = DefaultColumns()
+	}
+
+	values := make([][]string, len(resources))
+	natural := make([]int, len(cols))
+	for i, r := range resources {
+		values[i] = make([]string, len(cols))
+		for j, c := range cols {
+			v := l.value(r, c.Field)
+			values[i][j] = v
+			natural[j] = max(natural[j], display.Width(v))
+		}
+	}
+	widths := fit(cols, natural, width)
+
+	lines := make([]string, len(resources))
+	for i, r := range resources {
+		color := ""
+		if !plain && !l.NoColor {
+			color = l.color(r.Type)
+		}
+		parts := make([]string, len(widths))
+		for j, w := range widths {
+			v := display.Truncate(values[i][j], w, l.Truncate)
+			if j < len(widths)-1 {
+				v = display.Pad(v, w)
+			}
+			if f := cols[j].Field; color != "" && (f == "name" || f == "type" || f == "fullType") {
+				v = "\x1b[" + color + "m" + v + "\x1b[0m"
+			}
+			parts[j] = v
+		}
+		lines[i] = strings.Join(parts, columnSep)
+	}
+	return lines
+}
+
+// fit chooses column widths for the available width, shrinking the column
+// with the most room above its minimum one cell at a time.
+func fit(cols []Column, natural []int, width int) []int {
+	widths := make([]int, len(cols))
+	mins := make([]int, len(cols))
+	total := len(columnSep) * (len(cols) - 1)
+	for j, c := range cols {
+		widths[j] = natural[j]
+		if c.Max > 0 {
+			widths[j] = min(widths[j], c.Max)
+		}
+		mins[j] = c.Min
+		if mins[j] == 0 {
+			mins[j] = min(natural[j], 8)
+		}
+		mins[j] = min(mins[j], widths[j])
+		total += widths[j]
+	}
+
+	for total > width {
+		k, room := -1, 0
+		for j := range widths {
+			if r := widths[j] - mins[j]; r > room {
+				k, room = j, r
+			}
+		}
+		if k < 0 {
+			break
+		}
+		widths[k]--
+		total--
+	}
+
+	n := len(widths)
+	for total > width && n > 1 {
+		n--
+		total -= widths[n] + len(columnSep)
+	}
+	return widths[:n]
+}
+
+// DefaultColors colour resources by provider namespace.
+var DefaultColors = map[string]string{
+	"microsoft.compute":             "blue",
+	"microsoft.containerservice":    "bright-blue",
+	"microsoft.containerregistry":   "bright-blue",
+	"microsoft.storage":             "green",
+	"microsoft.web":                 "magenta",
+	"microsoft.app":                 "magenta",
+	"microsoft.network":             "cyan",
+	"microsoft.keyvault":            "yellow",
+	"microsoft.sql":                 "red",
+	"microsoft.dbforpostgresql":     "red",
+	"microsoft.dbformysql":          "red",
+	"microsoft.documentdb":          "red",
+	"microsoft.insights":            "gray",
+	"microsoft.operationalinsights": "gray",
+}
+
+// color returns the SGR parameters for a resource type: a configured colour
+// for the type or its namespace, then the default for the namespace.
+func (l Layout) color(typ string) string {
+	typ = strings.ToLower(typ)
+	namespace, _, _ := strings.Cut(typ, "/")
+	for _, table := range []map[string]string{l.Colors, DefaultColors} {
+		for _, key := range []string{typ, namespace} {
+			if c, ok := table[key]; ok {
+				code, _ := sgr(c)
+				return code
+			}
+		}
+	}
+	return ""
+}
+
+// colorNames are the basic ANSI colours by name.
+var colorNames = map[string]int{
+	"black": 0, "red": 1, "green": 2, "yellow": 3, "blue": 4, "magenta": 5, "cyan": 6, "white": 7,
+}
+
+// sgr converts a colour name ("red", "bright-red", "gray"), a 256-colour
+// number or "none" to SGR parameters.
+func sgr(c string) (string, error) {
+	c = strings.ToLower(strings.TrimSpace(c))
+	switch c {
+	case "none", "":
+		return "", nil
+	case "gray", "grey":
+		return "90", nil
+	}
+	if n, ok := colorNames[c]; ok {
+		return strconv.Itoa(30 + n), nil
+	}
+	if name, ok := strings.CutPrefix(c, "bright-"); ok {
+		if n, ok := colorNames[name]; ok {
+			return strconv.Itoa(90 + n), nil
+		}
+	}
+	if n, err := strconv.Atoi(c); err == nil && n >= 0 && n <= 255 {
+		return "38;5;" + strconv.Itoa(n), nil
+	}
+	return "", fmt.Errorf("unknown colour %q", c)
+}
+
+// ListWidth is the width left for list lines on a terminal width columns
+// wide when the preview takes previewPercent of it, after the two columns
+// pickers use for the cursor and the mark.
+func ListWidth(width, previewPercent int) int {
+	return width*(100-previewPercent)/100 - 3
+}
+
+// TerminalWidth returns the width of the terminal the picker draws on, or
+// 120 if it cannot be determined.
+func TerminalWidth() int {
+	for _, f := range []*os.File{os.Stderr, os.Stdout, os.Stdin} {
+		if w, _, err := term.GetSize(f.Fd()); err == nil && w > 0 {
+			return w
+		}
+	}
+	return 120
+}
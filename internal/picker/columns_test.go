@@ -0,0 +1,95 @@
+package picker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chege/azfind/internal/cache"
+)
+
+func TestLinesFitWidth(t *testing.T) {
+	resources := []cache.Resource{
+		{Name: "a-very-long-storage-account-name", Type: "Microsoft.Storage/storageAccounts", ResourceGroup: "rg-shared-storage"},
+		{Name: "kv", Type: "Microsoft.KeyVault/vaults", ResourceGroup: "rg1"},
+	}
+	l := Layout{NoColor: true}
+
+	wide := l.Lines(resources, 200, false)
+	if !strings.HasPrefix(wide[0], "a-very-long-storage-account-name | storageAccounts | rg-shared-storage") {
+		t.Fatalf("expected full values on a wide terminal, got %q", wide[0])
+	}
+	if !strings.HasPrefix(wide[1], "kv                               | vaults          | rg1") {
+		t.Fatalf("expected aligned columns, got %q", wide[1])
+	}
+
+	narrow := l.Lines(resources, 50, false)
+	for _, line := range narrow {
+		if n := len([]rune(line)); n > 50 {
+			t.Fatalf("expected at most 50 runes, got %d: %q", n, line)
+		}
+	}
+	if !strings.Contains(narrow[0], "...") || strings.Count(narrow[0], columnSep) != 2 {
+		t.Fatalf("expected shrunk columns, got %q", narrow[0])
+	}
+
+	tiny := l.Lines(resources, 25, false)
+	if strings.Contains(tiny[0], columnSep) {
+		t.Fatalf("expected trailing columns dropped, got %q", tiny[0])
+	}
+}
+
+func TestLinesFieldsAndColors(t *testing.T) {
+	r := cache.Resource{
+		Name: "app", Type: "Microsoft.Web/sites", SubscriptionID: "sub1", SubscriptionName: "Production", Location: "westeurope",
+		Tags: map[string]string{"env": "prod"},
+	}
+	l := Layout{
+		Columns: []Column{{Field: "name"}, {Field: "subscription"}, {Field: "location"}, {Field: "tag:env"}},
+	}
+	if got := l.Lines([]cache.Resource{r}, 200, true)[0]; got != "app | Production | westeurope | prod" {
+		t.Fatalf("unexpected line %q", got)
+	}
+
+	if got := l.Lines([]cache.Resource{r}, 200, false)[0]; !strings.HasPrefix(got, "\x1b[35mapp\x1b[0m") {
+		t.Fatalf("expected web apps in magenta, got %q", got)
+	}
+	l.Colors = map[string]string{"microsoft.web/sites": "208"}
+	if got := l.Lines([]cache.Resource{r}, 200, false)[0]; !strings.HasPrefix(got, "\x1b[38;5;208mapp") {
+		t.Fatalf("expected the configured colour, got %q", got)
+	}
+}
+
+func TestLinesAlignWideNames(t *testing.T) {
+	l := Layout{Columns: []Column{{Field: "name"}, {Field: "resourceGroup"}}}
+	lines := l.Lines([]cache.Resource{
+		{Name: "日本語", ResourceGroup: "rg1"},
+		{Name: "abcdef", ResourceGroup: "rg2"},
+	}, 80, true)
+	if lines[0] != "日本語 | rg1" || lines[1] != "abcdef | rg2" {
+		t.Fatalf("expected aligned columns, got %q", lines)
+	}
+}
+
+func TestLayoutValidate(t *testing.T) {
+	bad := []Layout{
+		{Columns: []Column{{Field: "colour"}}},
+		{Columns: []Column{{Field: "tag:"}}},
+		{Columns: []Column{{Field: "name", Min: 30, Max: 10}}},
+		{Truncate: "left"},
+		{Colors: map[string]string{"microsoft.web": "purple"}},
+	}
+	for _, l := range bad {
+		if err := l.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", l)
+		}
+	}
+
+	good := Layout{
+		Columns:  []Column{{Field: "name", Min: 10, Max: 40}, {Field: "tag:env"}},
+		Truncate: TruncateMiddle,
+		Colors:   map[string]string{"microsoft.web": "bright-magenta", "microsoft.sql": "none"},
+	}
+	if err := good.Validate(); err != nil {
+		t.Fatalf("expected valid layout: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+// Package picker defines the interactive resource picker used by the search
+// command and the pieces shared by its implementations.
+package picker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chege/azfind/internal/actions"
+	"github.com/chege/azfind/internal/cache"
+	"github.com/chege/azfind/internal/display"
+)
+
+// Picker lets the user choose resources and an action to run on them.
+type Picker interface {
+	// Select shows resources and returns the chosen ones. It returns
+	// ErrCancelled, ErrNoMatch or ErrPickerUnavailable when it ends without
+	// a selection for those reasons.
+	Select(resources []cache.Resource, opts Options) (*Selection, error)
+	// List shows plain lines, such as the levels of the tree browser, and
+	// returns the chosen one. It returns the same errors as Select.
+	List(lines []string, opts ListOptions) (*Choice, error)
+}
+
+var (
+	// ErrCancelled means the user dismissed the picker or its action menu.
+	ErrCancelled = errors.New("selection cancelled")
+	// ErrNoMatch means no resource matched the query.
+	ErrNoMatch = errors.New("no matching resources")
+	// ErrPickerUnavailable means the picker could not be started, e.g.
+	// because fzf is not installed.
+	ErrPickerUnavailable = errors.New("picker unavailable")
+)
+
+// Selection holds the picked resources together with the action to run on them.
+type Selection struct {
+	// Resources are the marked lines, or the highlighted one if none were marked.
+	Resources []cache.Resource
+	// Action is the name of the registry action chosen via key binding or menu.
+	Action string
+}
+
+// Options configures a picker run.
+type Options struct {
+	// Query pre-fills the search prompt.
+	Query string
+	// Header is shown above the list, e.g. to warn about a stale cache.
+	Header string
+	// Actions provides key bindings and the action menu.
+	Actions *actions.Registry
+	// DefaultAction runs on enter. Empty means actions.OpenPortal.
+	DefaultAction string
+	// Layout chooses the columns of the list lines.
+	Layout Layout
+	// Preview is the command that prints the preview of the highlighted
+	// resource, with the resource ID appended as the last argument. Nil
+	// shows the cached fields only.
+	Preview []string
+}
+
+// ListOptions configures Picker.List.
+type ListOptions struct {
+	// Prompt is shown before the query.
+	Prompt string
+	// Header is shown above the list.
+	Header string
+	// Keys accept the highlighted line like enter does, in fzf notation
+	// ("ctrl-o").
+	Keys []string
+}
+
+// Choice is the line chosen from Picker.List.
+type Choice struct {
+	// Index of the chosen line.
+	Index int
+	// Key that accepted it, or "" for enter.
+	Key string
+}
+
+// MenuKey opens the action menu for the highlighted resource.
+const MenuKey = "ctrl-a"
+
+// BladeKey opens a menu of portal blades for the highlighted resource.
+const BladeKey = "ctrl-b"
+
+// ChildrenKey lists the resources nested in the highlighted one, e.g. the
+// databases of a SQL server. The selection ends with ChildrenAction.
+const ChildrenKey = "ctrl-t"
+
+// ChildrenAction is the pseudo-action of ChildrenKey; the caller shows the
+// children of the selected resources in a new picker.
+const ChildrenAction = "children"
+
+// ReservedKeys are the keys the picker handles itself, which actions may not use.
+func ReservedKeys() []string {
+	return []string{MenuKey, BladeKey, ChildrenKey}
+}
+
+// Reserved returns the keys and pseudo-action names the picker handles
+// itself, for actions.Registry.RegisterConfig.
+func Reserved() actions.Reserved {
+	return actions.Reserved{Keys: ReservedKeys(), Names: []string{ChildrenAction}}
+}
+
+// Keys returns the keys that end a selection: those of the registry's
+// actions and ReservedKeys.
+func Keys(reg *actions.Registry) []string {
+	return append(reg.Keys(), ReservedKeys()...)
+}
+
+// BladeActions keeps the actions that open a portal blade.
+func BladeActions(list []actions.Action) []actions.Action {
+	var blades []actions.Action
+	for _, a := range list {
+		if strings.HasPrefix(a.Name, actions.BladePrefix) {
+			blades = append(blades, a)
+		}
+	}
+	return blades
+}
+
+// MenuPrompt names what an action menu applies to.
+func MenuPrompt(rs []cache.Resource) string {
+	if len(rs) == 1 {
+		return rs[0].Name
+	}
+	return fmt.Sprintf("%d resources", len(rs))
+}
+
+// Header prefixes the key binding hints with an optional message.
+func Header(msg, defaultAction string, reg *actions.Registry) string {
+	hints := []string{"enter " + defaultAction, "tab mark"}
+	for _, a := range reg.List() {
+		if a.Key != "" {
+			hints = append(hints, a.Key+" "+a.Name)
+		}
+	}
+	hints = append(hints, BladeKey+" blade", ChildrenKey+" children", MenuKey+" actions")
+
+	header := strings.Join(hints, " · ")
+	if msg != "" {
+		header = msg + "\n" + header
+	}
+	return header
+}
+
+// Freshness describes when a resource was last confirmed by a sync.
+func Freshness(updatedAt time.Time) string {
+	if updatedAt.IsZero() {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s (%s ago)", updatedAt.Local().Format("2006-01-02 15:04"), display.Age(time.Since(updatedAt)))
+}
+
+// MenuFunc asks the user to choose one of list and returns its name. It
+// returns ErrCancelled if the menu was dismissed.
+type MenuFunc func(list []actions.Action, prompt string) (string, error)
+
+// ChooseAction maps the key a selection ended with to an action name. Enter
+// (an empty key) gives defaultAction, MenuKey and BladeKey ask menu to choose
+// among the actions that apply to picked and ChildrenKey gives ChildrenAction.
+func ChooseAction(reg *actions.Registry, key, defaultAction string, picked []cache.Resource, menu MenuFunc) (string, error) {
+	switch a, ok := reg.ByKey(key); {
+	case key == MenuKey:
+		return menu(reg.ForAll(picked), MenuPrompt(picked))
+	case key == BladeKey:
+		return menu(BladeActions(reg.ForAll(picked)), MenuPrompt(picked)+" blade")
+	case key == ChildrenKey:
+		return ChildrenAction, nil
+	case ok:
+		return a.Name, nil
+	default:
+		return defaultAction, nil
+	}
+}
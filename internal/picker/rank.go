@@ -0,0 +1,47 @@
+package picker
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/chege/azfind/internal/cache"
+)
+
+// DefaultPreferTypes ranks the resources people usually mean first when
+// several share a name, e.g. an app service before its plan, Application
+// Insights component and managed identity.
+func DefaultPreferTypes() []string {
+	return []string{
+		"microsoft.web/sites",
+		"microsoft.app/containerapps",
+		"microsoft.containerservice/managedclusters",
+		"microsoft.compute/virtualmachines",
+		"microsoft.sql/servers",
+		"microsoft.dbforpostgresql/flexibleservers",
+		"microsoft.documentdb/databaseaccounts",
+		"microsoft.keyvault/vaults",
+		"microsoft.storage/storageaccounts",
+	}
+}
+
+// RankByType orders resources by the first entry of prefer that matches their
+// type or provider namespace, e.g. "microsoft.web/sites" or "microsoft.web".
+// Resources matching no entry come last; ties keep their order.
+func RankByType(resources []cache.Resource, prefer []string) []cache.Resource {
+	rank := func(r cache.Resource) int {
+		typ := strings.ToLower(r.Type)
+		namespace, _, _ := strings.Cut(typ, "/")
+		for i, p := range prefer {
+			if p = strings.ToLower(p); p == typ || p == namespace {
+				return i
+			}
+		}
+		return len(prefer)
+	}
+
+	ranked := slices.Clone(resources)
+	slices.SortStableFunc(ranked, func(a, b cache.Resource) int {
+		return rank(a) - rank(b)
+	})
+	return ranked
+}
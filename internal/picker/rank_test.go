@@ -0,0 +1,29 @@
+package picker
+
+import (
+	"testing"
+
+	"github.com/chege/azfind/internal/cache"
+)
+
+func TestRankByType(t *testing.T) {
+	resources := []cache.Resource{
+		{Name: "myapp", Type: "Microsoft.Insights/components"},
+		{Name: "myapp", Type: "Microsoft.ManagedIdentity/userAssignedIdentities"},
+		{Name: "myapp", Type: "Microsoft.Web/serverFarms"},
+		{Name: "myapp", Type: "Microsoft.Web/sites"},
+	}
+
+	got := RankByType(resources, DefaultPreferTypes())
+	if got[0].Type != "Microsoft.Web/sites" || got[1].Type != "Microsoft.Insights/components" {
+		t.Fatalf("expected the site first and the rest in order, got %+v", got)
+	}
+	if resources[0].Type != "Microsoft.Insights/components" {
+		t.Fatal("RankByType modified its input")
+	}
+
+	got = RankByType(resources, []string{"microsoft.managedidentity", "Microsoft.Web/serverfarms"})
+	if got[0].Type != "Microsoft.ManagedIdentity/userAssignedIdentities" || got[1].Type != "Microsoft.Web/serverFarms" {
+		t.Fatalf("expected the configured namespace and type first, got %+v", got)
+	}
+}
@@ -0,0 +1,130 @@
+package portal
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/chege/azfind/internal/armid"
+)
+
+// ErrNotResourceLink is wrapped by the errors ParseURL returns for text that
+// is not a link to a resource.
+var ErrNotResourceLink = errors.New("not a portal resource link")
+
+// ErrUnsupportedCloud is wrapped by the errors ParseURL returns for links to
+// the portal of a sovereign cloud; azf only syncs and opens the public cloud.
+var ErrUnsupportedCloud = errors.New("unsupported cloud")
+
+// publicHost is the host of Host. Subdomains such as ms.portal.azure.com are
+// accepted too.
+const publicHost = "portal.azure.com"
+
+// sovereignHosts are the portals of the sovereign clouds.
+var sovereignHosts = []string{
+	"portal.azure.us",
+	"portal.azure.cn",
+	"portal.microsoftazure.de",
+}
+
+// Link is a portal resource link as built by ResourceURL.
+type Link struct {
+	// Host is the portal the link points to, e.g. "ms.portal.azure.com".
+	Host string
+	// Tenant is the directory after "#@", a domain or tenant ID, or "" if
+	// the link has none.
+	Tenant string
+	// ID is the normalised resource ID.
+	ID string
+	// Blade is the portal path after the resource ID, e.g. "users", or ""
+	// for the overview.
+	Blade string
+}
+
+// IsURL reports whether s looks like a web link rather than a name or ID.
+func IsURL(s string) bool {
+	s = trimLink(s)
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+// ParseURL parses a portal resource link such as
+//
+//	https://portal.azure.com/#@contoso.onmicrosoft.com/resource/subscriptions/<id>/resourceGroups/<rg>/overview
+//
+// Links pasted from chat may be wrapped in angle brackets. The resource ID is
+// the longest prefix of the path that is a valid ID; the rest is the blade.
+// A blade of several segments may parse as part of a child resource ID;
+// Splits lists the shorter IDs to try.
+func ParseURL(s string) (Link, error) {
+	u, err := url.Parse(trimLink(s))
+	if err != nil {
+		return Link{}, fmt.Errorf("%w: %v", ErrNotResourceLink, err)
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range sovereignHosts {
+		if hostMatches(host, h) {
+			return Link{}, fmt.Errorf("%w: %s belongs to a sovereign cloud; azf only works with %s", ErrUnsupportedCloud, host, publicHost)
+		}
+	}
+	if !hostMatches(host, publicHost) {
+		return Link{}, fmt.Errorf("%w: unknown host %q", ErrNotResourceLink, u.Host)
+	}
+
+	link := Link{Host: host}
+	fragment := u.Fragment
+	if rest, ok := strings.CutPrefix(fragment, "@"); ok {
+		link.Tenant, fragment, _ = strings.Cut(rest, "/")
+		fragment = "/" + fragment
+	}
+	path, ok := strings.CutPrefix(fragment, "/resource/")
+	if !ok {
+		return Link{}, fmt.Errorf("%w: %q does not point to a resource", ErrNotResourceLink, s)
+	}
+
+	splits := link.split(path)
+	if len(splits) == 0 {
+		return Link{}, fmt.Errorf("%w: no resource ID in %q", ErrNotResourceLink, s)
+	}
+	return splits[0], nil
+}
+
+// Splits returns every way the path of l divides into a valid resource ID
+// and a blade, longest ID first; the first is l itself. In
+// ".../sites/app/settings/configuration" the blade "settings/configuration"
+// also reads as the child resource "settings/configuration" of app, so a
+// caller that knows which resources exist should try each in turn.
+func (l Link) Splits() []Link {
+	path := l.ID
+	if l.Blade != "" {
+		path += "/" + l.Blade
+	}
+	return l.split(path)
+}
+
+// split divides path like Splits, keeping the host and tenant of l.
+func (l Link) split(path string) []Link {
+	var splits []Link
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	for n := len(segs); n > 0; n-- {
+		id, err := armid.Parse("/" + strings.Join(segs[:n], "/"))
+		if err != nil {
+			continue
+		}
+		link := l
+		link.ID = id.String()
+		link.Blade = strings.Join(segs[n:], "/")
+		splits = append(splits, link)
+	}
+	return splits
+}
+
+// trimLink removes the space and angle brackets around links pasted from chat.
+func trimLink(s string) string {
+	return strings.Trim(strings.TrimSpace(s), "<>")
+}
+
+// hostMatches reports whether host is portal or one of its subdomains.
+func hostMatches(host, portal string) bool {
+	return host == portal || strings.HasSuffix(host, "."+portal)
+}
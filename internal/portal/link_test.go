@@ -0,0 +1,89 @@
+package portal
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	const id = "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Web/sites/app1"
+	cases := []struct {
+		in   string
+		want Link
+	}{
+		{ResourceURL("contoso.onmicrosoft.com", id, ""), Link{Host: "portal.azure.com", Tenant: "contoso.onmicrosoft.com", ID: id}},
+		{ResourceURL("tenant1", id, "iam"), Link{Host: "portal.azure.com", Tenant: "tenant1", ID: id, Blade: "users"}},
+		{ResourceURL("", id, ""), Link{Host: "portal.azure.com", ID: id}},
+		{
+			"<https://ms.portal.azure.com/#@tenant1/resource/subscriptions/sub1/resourcegroups/rg1/overview>",
+			Link{Host: "ms.portal.azure.com", Tenant: "tenant1", ID: "/subscriptions/sub1/resourceGroups/rg1", Blade: "overview"},
+		},
+		{
+			"https://portal.azure.com/?feature.x=true#@tenant1/resource" + id + "/",
+			Link{Host: "portal.azure.com", Tenant: "tenant1", ID: id},
+		},
+		{
+			"https://preview.portal.azure.com/#@tenant1/resource" + id + "/appServiceLogs",
+			Link{Host: "preview.portal.azure.com", Tenant: "tenant1", ID: id, Blade: "appServiceLogs"},
+		},
+	}
+	for _, tc := range cases {
+		got, err := ParseURL(tc.in)
+		if err != nil {
+			t.Errorf("%s: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: expected %+v, got %+v", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestLinkSplits(t *testing.T) {
+	const app = "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Web/sites/app1"
+	link, err := ParseURL("https://portal.azure.com/#@tenant1/resource" + app + "/settings/configuration")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	// The two-segment blade also parses as a child resource of app1.
+	want := []Link{
+		{Host: "portal.azure.com", Tenant: "tenant1", ID: app + "/settings/configuration"},
+		{Host: "portal.azure.com", Tenant: "tenant1", ID: app, Blade: "settings/configuration"},
+		{Host: "portal.azure.com", Tenant: "tenant1", ID: "/subscriptions/sub1/resourceGroups/rg1", Blade: "providers/Microsoft.Web/sites/app1/settings/configuration"},
+		{Host: "portal.azure.com", Tenant: "tenant1", ID: "/subscriptions/sub1", Blade: "resourceGroups/rg1/providers/Microsoft.Web/sites/app1/settings/configuration"},
+	}
+	if got := link.Splits(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected splits\n%+v\ngot\n%+v", want, got)
+	}
+}
+
+func TestParseURLInvalid(t *testing.T) {
+	for _, in := range []string{
+		"https://example.com/#@tenant1/resource/subscriptions/sub1",
+		"https://portal.azure.com/#view/HubsExtension/BrowseAll",
+		"https://portal.azure.com/#@tenant1/resource/not/an/id",
+		"https://evilportal.azure.com.example/#/resource/subscriptions/sub1",
+	} {
+		if _, err := ParseURL(in); !errors.Is(err, ErrNotResourceLink) {
+			t.Errorf("%s: expected ErrNotResourceLink, got %v", in, err)
+		}
+	}
+}
+
+func TestParseURLSovereignCloud(t *testing.T) {
+	for _, in := range []string{
+		"https://portal.azure.us/#@tenant1/resource/subscriptions/sub1/resourceGroups/rg1",
+		"https://portal.azure.cn/#@tenant1/resource/subscriptions/sub1",
+	} {
+		if _, err := ParseURL(in); !errors.Is(err, ErrUnsupportedCloud) {
+			t.Errorf("%s: expected ErrUnsupportedCloud, got %v", in, err)
+		}
+	}
+}
+
+func TestIsURL(t *testing.T) {
+	if !IsURL(" <https://portal.azure.com/#home>") || IsURL("kv-prod") || IsURL("/subscriptions/sub1") {
+		t.Fatal("IsURL misclassified its input")
+	}
+}
@@ -0,0 +1,71 @@
+package portal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Host is the public-cloud Azure Portal.
+const Host = "https://portal.azure.com"
+
+// Blade is a portal page of a resource, addressed by a path segment appended
+// to the resource URL.
+type Blade struct {
+	// Name is what users type, e.g. "iam".
+	Name string
+	// Path is the portal path segment, e.g. "users".
+	Path string
+	// Title is shown in menus.
+	Title string
+}
+
+// Blades are the blades available on (almost) every resource type.
+var Blades = []Blade{
+	{Name: "overview", Path: "overview", Title: "Overview"},
+	{Name: "iam", Path: "users", Title: "Access control (IAM)"},
+	{Name: "activity", Path: "eventlogs", Title: "Activity log"},
+	{Name: "logs", Path: "logs", Title: "Logs"},
+	{Name: "metrics", Path: "metrics", Title: "Metrics"},
+	{Name: "configuration", Path: "configuration", Title: "Settings: configuration"},
+	{Name: "networking", Path: "networking", Title: "Networking"},
+	{Name: "properties", Path: "properties", Title: "Properties"},
+	{Name: "locks", Path: "locks", Title: "Locks"},
+	{Name: "diagnostics", Path: "diagnostics", Title: "Diagnostic settings"},
+}
+
+// aliases are alternative names for Blades entries.
+var aliases = map[string]string{
+	"settings/configuration": "configuration",
+	"access":                 "iam",
+	"activity-log":           "activity",
+}
+
+// LookupBlade returns the blade called name. Unknown names are treated as raw
+// portal path segments, so any blade can be reached.
+func LookupBlade(name string) Blade {
+	key := strings.ToLower(strings.Trim(name, "/"))
+	if alias, ok := aliases[key]; ok {
+		key = alias
+	}
+	for _, b := range Blades {
+		if b.Name == key {
+			return b
+		}
+	}
+	path := strings.Trim(name, "/")
+	return Blade{Name: path, Path: path, Title: path}
+}
+
+// ResourceURL returns the portal URL of the resource with the given ID in
+// tenant. A non-empty blade is looked up with LookupBlade and appended. An
+// empty tenant leaves the choice of directory to the portal.
+func ResourceURL(tenant, id, blade string) string {
+	url := fmt.Sprintf("%s/#@%s/resource%s", Host, tenant, id)
+	if tenant == "" {
+		url = fmt.Sprintf("%s/#/resource%s", Host, id)
+	}
+	if blade == "" {
+		return url
+	}
+	return url + "/" + LookupBlade(blade).Path
+}
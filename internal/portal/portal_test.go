@@ -0,0 +1,30 @@
+package portal
+
+import "testing"
+
+func TestResourceURL(t *testing.T) {
+	const id = "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Web/sites/app1"
+	base := "https://portal.azure.com/#@tenant1/resource" + id
+
+	cases := []struct {
+		blade string
+		want  string
+	}{
+		{"", base},
+		{"overview", base + "/overview"},
+		{"iam", base + "/users"},
+		{"IAM", base + "/users"},
+		{"activity", base + "/eventlogs"},
+		{"settings/configuration", base + "/configuration"},
+		{"/appServiceLogs/", base + "/appServiceLogs"},
+	}
+	for _, tc := range cases {
+		if got := ResourceURL("tenant1", id, tc.blade); got != tc.want {
+			t.Errorf("blade %q: expected %s, got %s", tc.blade, tc.want, got)
+		}
+	}
+
+	if got, want := ResourceURL("", id, ""), "https://portal.azure.com/#/resource"+id; got != want {
+		t.Errorf("without tenant: expected %s, got %s", want, got)
+	}
+}
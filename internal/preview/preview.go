@@ -0,0 +1,265 @@
+// Package preview renders the details of a cached resource for the picker's
+// preview pane.
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/chege/azfind/internal/armid"
+	"github.com/chege/azfind/internal/azure"
+	"github.com/chege/azfind/internal/cache"
+	"github.com/chege/azfind/internal/picker"
+)
+
+// Options controls what Load gathers.
+type Options struct {
+	// Related caps the other resources of the resource group that are listed.
+	Related int
+	// Children caps the nested resources that are listed, e.g. the databases
+	// of a SQL server.
+	Children int
+	// History caps the recent actions that are listed.
+	History int
+	// Live fetches current details from Resource Graph.
+	Live bool
+	// Timeout bounds the live lookup; zero means no limit beyond ctx.
+	Timeout time.Duration
+	// ClientOptions configure the Azure client of the live lookup. Nil uses
+	// the SDK defaults.
+	ClientOptions *arm.ClientOptions
+}
+
+// Details is everything shown in the preview of a resource.
+type Details struct {
+	Resource cache.Resource
+	// Opens counts the actions ever run on the resource; Recent holds the
+	// latest of them, newest first.
+	Opens  int
+	Recent []cache.OpenRecord
+	// Related are other resources of the same resource group.
+	Related []cache.Resource
+	// Parent is the resource this one is nested in, if cached. Children are
+	// the resources nested in it.
+	Parent   *cache.Resource
+	Children []cache.Resource
+	// Live is the current Resource Graph row when Options.Live was set and
+	// the lookup succeeded; LiveErr explains why it is missing otherwise.
+	Live    map[string]any
+	LiveErr error
+}
+
+// Load gathers the details of the cached resource with the given ID. It
+// returns nil if the resource is not cached. A failed live lookup is
+// reported in Details.LiveErr rather than as an error.
+func Load(ctx context.Context, db *cache.DB, id string, opts Options) (*Details, error) {
+	r, err := db.FindResourceByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find resource: %w", err)
+	}
+	if r == nil {
+		return nil, nil
+	}
+	d := &Details{Resource: *r}
+
+	if opts.History > 0 {
+		d.Opens, d.Recent, err = db.OpenHistory(ctx, r.ID, opts.History)
+		if err != nil {
+			return nil, fmt.Errorf("load open history: %w", err)
+		}
+	}
+
+	if opts.Related > 0 && r.ResourceGroup != "" {
+		// One extra row makes up for the resource itself.
+		group, err := db.FindResourcesInGroup(ctx, r.SubscriptionID, r.ResourceGroup, opts.Related+1)
+		if err != nil {
+			return nil, fmt.Errorf("load related resources: %w", err)
+		}
+		for _, g := range group {
+			if !strings.EqualFold(g.ID, r.ID) && len(d.Related) < opts.Related {
+				d.Related = append(d.Related, g)
+			}
+		}
+	}
+
+	if r.ParentID != "" {
+		if d.Parent, err = db.FindResourceByID(ctx, r.ParentID); err != nil {
+			return nil, fmt.Errorf("load parent: %w", err)
+		}
+	}
+	if opts.Children > 0 {
+		children, err := db.FindChildren(ctx, r.ID)
+		if err != nil {
+			return nil, fmt.Errorf("load children: %w", err)
+		}
+		d.Children = children[:min(len(children), opts.Children)]
+	}
+
+	if opts.Live {
+		d.Live, d.LiveErr = fetchLive(ctx, *r, opts)
+	}
+	return d, nil
+}
+
+// fetchLive looks r up in Resource Graph. The preview command runs for every
+// highlighted row, so it only uses credentials that cannot prompt; without
+// any it returns neither a row nor an error and the live section is left out.
+func fetchLive(ctx context.Context, r cache.Resource, opts Options) (map[string]any, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	cred, err := azure.GetSilentCredential()
+	if errors.Is(err, azure.ErrNoSilentCredential) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed: %w", err)
+	}
+	row, err := azure.GetResource(ctx, cred, r.SubscriptionID, r.ID, opts.ClientOptions)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s", opts.Timeout)
+	}
+	return row, err
+}
+
+// maxLiveProperties caps the live properties shown; the pane is small.
+const maxLiveProperties = 12
+
+// Render writes d as text, with ANSI colours if color is set.
+func Render(w io.Writer, d *Details, color bool) {
+	p := palette(color)
+	r := d.Resource
+
+	_, _ = fmt.Fprintln(w, p.paint(bold, r.Name))
+	_, _ = fmt.Fprintln(w, p.paint(cyan, r.Type))
+	_, _ = fmt.Fprintln(w)
+
+	field := func(label, value string) {
+		if value != "" {
+			_, _ = fmt.Fprintf(w, "%s %s\n", p.paint(dim, fmt.Sprintf("%-15s", label)), value)
+		}
+	}
+	sub := r.SubscriptionID
+	if r.SubscriptionName != "" {
+		sub = fmt.Sprintf("%s (%s)", r.SubscriptionName, r.SubscriptionID)
+	}
+	field("Subscription", sub)
+	tenant := r.TenantLabel()
+	if r.TenantName != "" && r.TenantDomain != "" {
+		tenant = fmt.Sprintf("%s (%s)", r.TenantName, r.TenantDomain)
+	}
+	field("Tenant", tenant)
+	field("Resource group", r.ResourceGroup)
+	if d.Parent != nil {
+		field("Parent", d.Parent.Name+" "+p.paint(dim, armid.ShortType(d.Parent.Type)))
+	}
+	field("Location", r.Location)
+	field("SKU", r.SKU)
+	field("Synced", picker.Freshness(r.UpdatedAt))
+	field("ID", r.ID)
+
+	if len(r.Tags) > 0 {
+		heading(w, p, "Tags")
+		for _, k := range slices.Sorted(maps.Keys(r.Tags)) {
+			_, _ = fmt.Fprintf(w, "  %s = %s\n", p.paint(green, k), r.Tags[k])
+		}
+	}
+
+	if d.Opens > 0 {
+		times := "times"
+		if d.Opens == 1 {
+			times = "time"
+		}
+		heading(w, p, fmt.Sprintf("Used %d %s", d.Opens, times))
+		for _, o := range d.Recent {
+			_, _ = fmt.Fprintf(w, "  %s  %s\n", p.paint(dim, o.At.Local().Format("2006-01-02 15:04")), o.Action)
+		}
+	}
+
+	switch {
+	case d.LiveErr != nil:
+		heading(w, p, "Live")
+		_, _ = fmt.Fprintln(w, "  "+p.paint(red, "unavailable: "+d.LiveErr.Error()))
+	case d.Live != nil:
+		heading(w, p, "Live")
+		renderLive(w, p, d.Live)
+	}
+
+	if len(d.Children) > 0 {
+		heading(w, p, "Children")
+		for _, c := range d.Children {
+			_, _ = fmt.Fprintf(w, "  %-30s %s\n", c.Name, p.paint(dim, armid.ShortType(c.Type)))
+		}
+	}
+
+	if len(d.Related) > 0 {
+		heading(w, p, "Also in "+r.ResourceGroup)
+		for _, g := range d.Related {
+			_, _ = fmt.Fprintf(w, "  %-30s %s\n", g.Name, p.paint(dim, armid.ShortType(g.Type)))
+		}
+	}
+}
+
+// renderLive lists the kind and the scalar properties of a Resource Graph row.
+func renderLive(w io.Writer, p palette, row map[string]any) {
+	values := map[string]string{}
+	if kind, ok := row["kind"].(string); ok && kind != "" {
+		values["kind"] = kind
+	}
+	if props, ok := row["properties"].(map[string]any); ok {
+		for k, v := range props {
+			switch v := v.(type) {
+			case string, bool, float64:
+				values[k] = fmt.Sprint(v)
+			}
+		}
+	}
+	if len(values) == 0 {
+		_, _ = fmt.Fprintln(w, "  "+p.paint(dim, "no properties"))
+		return
+	}
+
+	keys := slices.Sorted(maps.Keys(values))
+	for i, k := range keys {
+		if i == maxLiveProperties {
+			_, _ = fmt.Fprintln(w, "  "+p.paint(dim, fmt.Sprintf("... %d more", len(keys)-i)))
+			break
+		}
+		_, _ = fmt.Fprintf(w, "  %s %s\n", p.paint(dim, fmt.Sprintf("%-22s", k)), values[k])
+	}
+}
+
+func heading(w io.Writer, p palette, title string) {
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, p.paint(yellow, title))
+}
+
+// ANSI SGR codes used by the preview.
+const (
+	bold   = "1"
+	dim    = "90"
+	red    = "31"
+	green  = "32"
+	yellow = "33"
+	cyan   = "36"
+)
+
+// palette colours text when true.
+type palette bool
+
+func (p palette) paint(code, s string) string {
+	if !p || s == "" {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
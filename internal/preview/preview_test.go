@@ -0,0 +1,111 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chege/azfind/internal/cache"
+)
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	db, err := cache.Open(ctx)
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	kv := "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv-prod"
+	resources := []cache.Resource{
+		{ID: kv, Name: "kv-prod", Type: "Microsoft.KeyVault/vaults", SubscriptionID: "sub1", ResourceGroup: "rg1"},
+		{ID: "2", Name: "app", Type: "Microsoft.Web/sites", SubscriptionID: "sub1", ResourceGroup: "rg1"},
+		{ID: "3", Name: "other", Type: "Microsoft.Web/sites", SubscriptionID: "sub1", ResourceGroup: "rg2"},
+		{ID: kv + "/secrets/db-password", Name: "kv-prod/db-password", Type: "Microsoft.KeyVault/vaults/secrets", SubscriptionID: "sub1", ResourceGroup: "rg1"},
+	}
+	if err := db.InsertResources(ctx, resources); err != nil {
+		t.Fatalf("insert resources: %v", err)
+	}
+	if err := db.RecordOpens(ctx, "open", time.Now(), kv); err != nil {
+		t.Fatalf("record open: %v", err)
+	}
+
+	d, err := Load(ctx, db, strings.ToUpper(kv), Options{Related: 5, Children: 5, History: 3})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if d == nil || d.Resource.Name != "kv-prod" {
+		t.Fatalf("expected kv-prod, got %+v", d)
+	}
+	if d.Opens != 1 || len(d.Recent) != 1 {
+		t.Fatalf("expected one recorded open, got %d %+v", d.Opens, d.Recent)
+	}
+	if len(d.Related) != 2 {
+		t.Fatalf("expected app and the secret as related resources, got %+v", d.Related)
+	}
+	if len(d.Children) != 1 || d.Children[0].Name != "kv-prod/db-password" {
+		t.Fatalf("expected the secret as the only child, got %+v", d.Children)
+	}
+
+	secret, err := Load(ctx, db, kv+"/secrets/db-password", Options{})
+	if err != nil {
+		t.Fatalf("load secret: %v", err)
+	}
+	if secret.Parent == nil || secret.Parent.Name != "kv-prod" {
+		t.Fatalf("expected kv-prod as the parent, got %+v", secret.Parent)
+	}
+
+	if d, err := Load(ctx, db, "missing", Options{}); err != nil || d != nil {
+		t.Fatalf("expected nil for an uncached id, got %+v, %v", d, err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	d := &Details{
+		Resource: cache.Resource{
+			ID: "/subscriptions/sub1/x", Name: "st1", Type: "Microsoft.Storage/storageAccounts",
+			SubscriptionID: "sub1", SubscriptionName: "Production",
+			TenantID: "t1", TenantName: "Contoso", TenantDomain: "contoso.onmicrosoft.com", ResourceGroup: "rg1", SKU: "Standard_LRS", Tags: map[string]string{"env": "prod"},
+		},
+		Opens:   2,
+		Recent:  []cache.OpenRecord{{Action: "copy-id", At: time.Now()}},
+		Related: []cache.Resource{{Name: "kv1", Type: "Microsoft.KeyVault/vaults"}},
+		Live:    map[string]any{"kind": "StorageV2", "properties": map[string]any{"accessTier": "Hot", "encryption": map[string]any{}}},
+	}
+
+	var buf bytes.Buffer
+	Render(&buf, d, false)
+	out := buf.String()
+	for _, want := range []string{"st1", "Production (sub1)", "Contoso (contoso.onmicrosoft.com)", "Standard_LRS", "env = prod", "Used 2 times", "copy-id", "kind", "StorageV2", "accessTier", "Also in rg1", "kv1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in preview:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "encryption") || strings.Contains(out, "\x1b[") {
+		t.Errorf("expected no nested properties and no colour:\n%s", out)
+	}
+
+	buf.Reset()
+	d.Live, d.LiveErr = nil, errors.New("timed out after 3s")
+	Render(&buf, d, true)
+	if !strings.Contains(buf.String(), "\x1b[") || !strings.Contains(buf.String(), "unavailable: timed out after 3s") {
+		t.Errorf("expected coloured output with the live error:\n%s", buf.String())
+	}
+}
+
+func TestFetchLiveWithoutSilentCredentials(t *testing.T) {
+	for _, k := range []string{"AZURE_TENANT_ID", "AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET", "AZURE_CLIENT_CERTIFICATE_PATH", "AZURE_USERNAME"} {
+		t.Setenv(k, "")
+	}
+	t.Setenv("PATH", t.TempDir())
+
+	row, err := fetchLive(context.Background(), cache.Resource{ID: "1", SubscriptionID: "sub1"}, Options{Live: true})
+	if row != nil || err != nil {
+		t.Fatalf("expected the live section to be skipped, got %v, %v", row, err)
+	}
+}
@@ -0,0 +1,106 @@
+// Package saved checks and resolves saved queries: named searches from the
+// "queries:" section of the config file, run as "azf @<name>".
+package saved
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chege/azfind/internal/output"
+)
+
+// Prefix marks a saved query on the command line, e.g. "azf @prod-kv".
+const Prefix = "@"
+
+// Query is an entry of "queries:". Exactly one of Search and KQL is set.
+type Query struct {
+	Name        string `mapstructure:"name"`
+	Description string `mapstructure:"description"`
+	// Search is in the search syntax, e.g. "type:keyvault loc:westeurope".
+	Search string `mapstructure:"search"`
+	// KQL is a Resource Graph query.
+	KQL string `mapstructure:"kql"`
+	// Output prints the rows of a KQL query in this format instead of
+	// showing them in the picker.
+	Output string `mapstructure:"output"`
+}
+
+// Kind returns "kql" or "search".
+func (q Query) Kind() string {
+	if q.KQL != "" {
+		return "kql"
+	}
+	return "search"
+}
+
+// Text returns the query on one line.
+func (q Query) Text() string {
+	if q.KQL != "" {
+		return strings.Join(strings.Fields(q.KQL), " ")
+	}
+	return q.Search
+}
+
+// Terms returns the search terms of q followed by extra. KQL queries take no
+// extra terms.
+func (q Query) Terms(extra []string) ([]string, error) {
+	if q.KQL != "" {
+		if len(extra) > 0 {
+			return nil, fmt.Errorf("saved query %q is KQL and takes no search terms", q.Name)
+		}
+		return nil, nil
+	}
+	return append(strings.Fields(q.Search), extra...), nil
+}
+
+// Validate reports entries without a name, names used twice (ignoring case),
+// entries with both or neither of search and kql, and bad output formats.
+func Validate(queries []Query) error {
+	seen := map[string]bool{}
+	for _, q := range queries {
+		switch {
+		case q.Name == "":
+			return errors.New("an entry has no name")
+		case seen[strings.ToLower(q.Name)]:
+			return fmt.Errorf("%q is defined twice", q.Name)
+		case (q.Search == "") == (q.KQL == ""):
+			return fmt.Errorf("%q needs either search or kql", q.Name)
+		case q.Output != "" && q.KQL == "":
+			return fmt.Errorf("%q sets output, which only applies to kql", q.Name)
+		}
+		if q.Output != "" {
+			if err := output.Validate(q.Output); err != nil {
+				return fmt.Errorf("%q: %w", q.Name, err)
+			}
+		}
+		seen[strings.ToLower(q.Name)] = true
+	}
+	return nil
+}
+
+// ErrNotFound is returned by Find for an unknown name.
+var ErrNotFound = errors.New("no saved query")
+
+// Find returns the query called name, ignoring case.
+func Find(queries []Query, name string) (Query, error) {
+	for _, q := range queries {
+		if strings.EqualFold(q.Name, name) {
+			return q, nil
+		}
+	}
+	return Query{}, fmt.Errorf("%w named %q", ErrNotFound, name)
+}
+
+// FromArgs reports whether args run a saved query, i.e. start with
+// "@<name>", and returns the name and the remaining terms.
+func FromArgs(args []string) (name string, extra []string, ok bool) {
+	if len(args) == 0 {
+		return "", nil, false
+	}
+	name, ok = strings.CutPrefix(args[0], Prefix)
+	if !ok || name == "" {
+		return "", nil, false
+	}
+	return name, args[1:], true
+}
@@ -0,0 +1,88 @@
+package saved
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries []Query
+		wantErr bool
+	}{
+		{"search and kql entries", []Query{{Name: "kv", Search: "type:keyvault"}, {Name: "vms", KQL: "Resources", Output: "csv"}}, false},
+		{"both search and kql", []Query{{Name: "kv", Search: "kv", KQL: "Resources"}}, true},
+		{"neither search nor kql", []Query{{Name: "kv"}}, true},
+		{"no name", []Query{{Search: "kv"}}, true},
+		{"name used twice", []Query{{Name: "kv", Search: "kv"}, {Name: "KV", Search: "vault"}}, true},
+		{"output on a search", []Query{{Name: "kv", Search: "kv", Output: "json"}}, true},
+		{"unknown output", []Query{{Name: "vms", KQL: "Resources", Output: "yaml"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.queries); (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFindAndTerms(t *testing.T) {
+	queries := []Query{
+		{Name: "prod-kv", Search: "sub:prod  type:keyvault"},
+		{Name: "vms", KQL: "Resources | project id"},
+	}
+	tests := []struct {
+		name      string
+		query     string
+		extra     []string
+		wantTerms []string
+		wantErr   bool
+	}{
+		{"search with extra terms", "PROD-KV", []string{"loc:westeurope"}, []string{"sub:prod", "type:keyvault", "loc:westeurope"}, false},
+		{"kql", "vms", nil, nil, false},
+		{"extra terms on kql", "vms", []string{"web"}, nil, true},
+		{"unknown name", "nope", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Find(queries, tt.query)
+			if err != nil {
+				if !tt.wantErr || !errors.Is(err, ErrNotFound) {
+					t.Fatalf("find %q: %v", tt.query, err)
+				}
+				return
+			}
+			terms, err := q.Terms(tt.extra)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(terms, tt.wantTerms) {
+				t.Fatalf("expected terms %q, got %q", tt.wantTerms, terms)
+			}
+		})
+	}
+}
+
+func TestFromArgs(t *testing.T) {
+	tests := []struct {
+		args      []string
+		wantName  string
+		wantExtra []string
+		wantOK    bool
+	}{
+		{[]string{"@prod-kv", "web"}, "prod-kv", []string{"web"}, true},
+		{[]string{"@prod-kv"}, "prod-kv", []string{}, true},
+		{[]string{"kv", "@prod-kv"}, "", nil, false},
+		{[]string{"@"}, "", nil, false},
+		{nil, "", nil, false},
+	}
+	for _, tt := range tests {
+		name, extra, ok := FromArgs(tt.args)
+		if name != tt.wantName || ok != tt.wantOK || !reflect.DeepEqual(extra, tt.wantExtra) {
+			t.Errorf("FromArgs(%q) = %q, %q, %v; want %q, %q, %v", tt.args, name, extra, ok, tt.wantName, tt.wantExtra, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/chege/azfind/internal/cache"
+)
+
+// StartBackground launches a detached "azf --sync" process unless a sync is
+// already running. It reports whether a new process was started. Output of the
+// background run is appended to sync.log in the cache directory.
+func StartBackground() (bool, error) {
+	if Running() {
+		return false, nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return false, fmt.Errorf("resolve executable: %w", err)
+	}
+
+	dir, err := cache.Dir()
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return false, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	logFile, err := os.OpenFile(filepath.Join(dir, "sync.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return false, fmt.Errorf("open sync log: %w", err)
+	}
+	defer func() {
+		_ = logFile.Close()
+	}()
+
+	cmd := exec.Command(exe, "--sync")
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return false, fmt.Errorf("start background sync: %w", err)
+	}
+	_ = cmd.Process.Release()
+
+	return true, nil
+}
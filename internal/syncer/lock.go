@@ -0,0 +1,94 @@
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chege/azfind/internal/cache"
+)
+
+// ErrSyncInProgress is returned when another sync already holds the lock.
+var ErrSyncInProgress = errors.New("another sync is already running")
+
+// lockStaleAfter is how long a lock file may live before it is assumed to be
+// left over from a crashed sync and can be taken over, when it cannot be told
+// whether the process that wrote it still runs.
+const lockStaleAfter = 2 * time.Hour
+
+func lockPath() (string, error) {
+	dir, err := cache.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sync.lock"), nil
+}
+
+// acquireLock creates the sync lock file and returns a func that releases it.
+func acquireLock() (func(), error) {
+	path, err := lockPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		if !lockIsStale(path) {
+			return nil, ErrSyncInProgress
+		}
+		_ = os.Remove(path)
+		f, err = os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			return nil, ErrSyncInProgress
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create sync lock: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+	_ = f.Close()
+
+	return func() { _ = os.Remove(path) }, nil
+}
+
+// lockIsStale reports whether the lock at path was left behind: its process
+// has exited or, if that cannot be checked, the lock is older than
+// lockStaleAfter. A long sync keeps its lock however long it runs.
+func lockIsStale(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return true
+	}
+	if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && pid > 0 {
+		if alive, known := processAlive(pid); known {
+			return !alive
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+	return time.Since(info.ModTime()) > lockStaleAfter
+}
+
+// Running reports whether a sync currently holds the lock.
+func Running() bool {
+	path, err := lockPath()
+	if err != nil {
+		return false
+	}
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+	return !lockIsStale(path)
+}
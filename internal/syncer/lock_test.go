@@ -0,0 +1,73 @@
+package syncer
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// writeLock leaves a lock file written by pid that was last touched age ago.
+func writeLock(t *testing.T, pid string, age time.Duration) {
+	t.Helper()
+	path, err := lockPath()
+	if err != nil {
+		t.Fatalf("lock path: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("create cache dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(pid+"\n"), 0o644); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+	mtime := time.Now().Add(-age)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("age lock: %v", err)
+	}
+}
+
+// exitedPID returns the PID of a process that has already exited.
+func exitedPID(t *testing.T) string {
+	t.Helper()
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot run a process: %v", err)
+	}
+	return strconv.Itoa(cmd.Process.Pid)
+}
+
+func TestAcquireLock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("processes are only checked on unix")
+	}
+	self := strconv.Itoa(os.Getpid())
+	tests := []struct {
+		name    string
+		pid     string
+		age     time.Duration
+		wantErr error
+	}{
+		{"live sync older than the stale age", self, 3 * lockStaleAfter, ErrSyncInProgress},
+		{"exited sync", exitedPID(t), time.Minute, nil},
+		{"unreadable pid, fresh", "", time.Minute, ErrSyncInProgress},
+		{"unreadable pid, old", "", 3 * lockStaleAfter, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+			writeLock(t, tt.pid, tt.age)
+
+			release, err := acquireLock()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err == nil {
+				release()
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package syncer
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive reports whether a process with pid exists by sending it
+// signal 0. known is false if that could not be determined.
+func processAlive(pid int) (alive, known bool) {
+	err := syscall.Kill(pid, 0)
+	switch {
+	case err == nil, errors.Is(err, syscall.EPERM):
+		// EPERM: the process exists but belongs to another user.
+		return true, true
+	case errors.Is(err, syscall.ESRCH):
+		return false, true
+	default:
+		return false, false
+	}
+}
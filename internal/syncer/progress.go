@@ -0,0 +1,214 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/mattn/go-isatty"
+	"github.com/rodaine/table"
+)
+
+// Subscription identifies a subscription being synced.
+type Subscription struct {
+	ID          string
+	DisplayName string
+}
+
+// Label returns the display name, falling back to the subscription ID.
+func (s Subscription) Label() string {
+	if s.DisplayName != "" {
+		return s.DisplayName
+	}
+	return s.ID
+}
+
+// SubscriptionResult describes the outcome of syncing one subscription.
+type SubscriptionResult struct {
+	Subscription
+	Resources int
+	Added     int
+	Updated   int
+	Removed   int
+	// Truncated is set when the listing hit maxResources; rows missing from
+	// it were kept rather than removed.
+	Truncated bool
+	Started   time.Time
+	Duration  time.Duration
+	Err       error
+}
+
+// Summary is the outcome of a whole sync run.
+type Summary struct {
+	Results  []SubscriptionResult
+	Duration time.Duration
+	// Resumed counts the subscriptions synced by the earlier run this one
+	// resumed; they are not in Results.
+	Resumed int
+	// Err is why the run ended early, e.g. context.Canceled when it was
+	// interrupted.
+	Err error
+}
+
+// Cancelled reports whether the run was interrupted.
+func (s Summary) Cancelled() bool {
+	return errors.Is(s.Err, context.Canceled) || errors.Is(s.Err, context.DeadlineExceeded)
+}
+
+// Failed returns the results of subscriptions that could not be synced.
+func (s Summary) Failed() []SubscriptionResult {
+	var failed []SubscriptionResult
+	for _, r := range s.Results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// Total returns the number of resources cached across successful subscriptions.
+func (s Summary) Total() int {
+	total := 0
+	for _, r := range s.Results {
+		if r.Err == nil {
+			total += r.Resources
+		}
+	}
+	return total
+}
+
+// Reporter receives progress events from SyncAll.
+type Reporter interface {
+	// Start is called once with every subscription that will be synced.
+	Start(subs []Subscription)
+	// SubscriptionStarted is called before resources of sub are listed.
+	SubscriptionStarted(sub Subscription)
+	// SubscriptionDone is called when a subscription finished, successfully or not.
+	SubscriptionDone(result SubscriptionResult)
+	// Finish is called once at the end of the run.
+	Finish(summary Summary)
+	// Notice reports a one-line message about the run, e.g. that it resumes
+	// an earlier one.
+	Notice(msg string)
+}
+
+// NewReporter returns a live-updating reporter when w is a terminal and a
+// line-oriented one otherwise (pipes, log files, CI).
+func NewReporter(w io.Writer) Reporter {
+	if f, ok := w.(*os.File); ok && isatty.IsTerminal(f.Fd()) {
+		return newTTYReporter(w, func() (int, int) {
+			width, height, err := term.GetSize(f.Fd())
+			if err != nil {
+				return 0, 0
+			}
+			return width, height
+		})
+	}
+	return &plainReporter{w: w}
+}
+
+// plainReporter writes one line per event and never rewrites output.
+type plainReporter struct {
+	w io.Writer
+}
+
+func (p *plainReporter) Start(subs []Subscription) {
+	_, _ = fmt.Fprintf(p.w, "Syncing %d subscriptions\n", len(subs))
+}
+
+func (p *plainReporter) SubscriptionStarted(sub Subscription) {
+	_, _ = fmt.Fprintf(p.w, "Syncing subscription: %s (%s)\n", sub.Label(), sub.ID)
+}
+
+func (p *plainReporter) SubscriptionDone(r SubscriptionResult) {
+	if r.Err != nil {
+		_, _ = fmt.Fprintf(p.w, "  ✗ %s failed after %s: %v\n", r.Label(), formatDuration(r.Duration), r.Err)
+		return
+	}
+	_, _ = fmt.Fprintf(p.w, "  → Synced %d resources in %s (%s)\n", r.Resources, formatDuration(r.Duration), formatChanges(r))
+	if r.Truncated {
+		_, _ = fmt.Fprintf(p.w, "  ! %s\n", truncatedWarning)
+	}
+}
+
+func (p *plainReporter) Notice(msg string) {
+	_, _ = fmt.Fprintln(p.w, msg)
+}
+
+func (p *plainReporter) Finish(s Summary) {
+	writeSummary(p.w, s)
+}
+
+// writeSummary prints the per-subscription table, the list of failures and
+// how the run ended.
+func writeSummary(w io.Writer, s Summary) {
+	if len(s.Results) == 0 {
+		switch {
+		case s.Err != nil:
+			writeOutcome(w, s)
+		case s.Resumed > 0:
+			_, _ = fmt.Fprintf(w, "Nothing left to resume; %d subscription(s) were already synced.\n", s.Resumed)
+		default:
+			_, _ = fmt.Fprintln(w, "No subscriptions found.")
+		}
+		return
+	}
+
+	_, _ = fmt.Fprintln(w)
+	tbl := table.New("Subscription", "Resources", "Added", "Updated", "Removed", "Duration", "Status").WithWriter(w)
+	for _, r := range s.Results {
+		status := "ok"
+		switch {
+		case r.Err != nil:
+			status = "failed"
+		case r.Truncated:
+			status = "truncated"
+		}
+		tbl.AddRow(r.Label(), r.Resources, r.Added, r.Updated, r.Removed, formatDuration(r.Duration), status)
+	}
+	tbl.Print()
+
+	if failed := s.Failed(); len(failed) > 0 {
+		_, _ = fmt.Fprintf(w, "\n%d subscription(s) failed:\n", len(failed))
+		for _, r := range failed {
+			_, _ = fmt.Fprintf(w, "  %s (%s): %v\n", r.Label(), r.ID, r.Err)
+		}
+	}
+
+	_, _ = fmt.Fprintln(w)
+	writeOutcome(w, s)
+}
+
+// writeOutcome prints the last line of the summary. Totals of a resumed or
+// interrupted run only cover the subscriptions it synced itself.
+func writeOutcome(w io.Writer, s Summary) {
+	d := formatDuration(s.Duration)
+	switch {
+	case s.Cancelled():
+		_, _ = fmt.Fprintf(w, "Sync cancelled after %s. Resources cached by this run: %d\n", d, s.Total())
+	case s.Err != nil:
+		_, _ = fmt.Fprintf(w, "Sync failed after %s. Resources cached by this run: %d\n", d, s.Total())
+	case s.Resumed > 0:
+		_, _ = fmt.Fprintf(w, "Resumed sync completed in %s. Resources cached by this run: %d (%d subscription(s) were synced before resuming)\n", d, s.Total(), s.Resumed)
+	default:
+		_, _ = fmt.Fprintf(w, "Sync completed in %s. Total resources cached: %d\n", d, s.Total())
+	}
+}
+
+// truncatedWarning explains a SubscriptionResult with Truncated set.
+var truncatedWarning = fmt.Sprintf("listing stopped at %d resources; resources not listed were kept in the cache", maxResources)
+
+func formatChanges(r SubscriptionResult) string {
+	return fmt.Sprintf("+%d ~%d -%d", r.Added, r.Updated, r.Removed)
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
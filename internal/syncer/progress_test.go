@@ -0,0 +1,111 @@
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestPlainReporterSummary(t *testing.T) {
+	var buf bytes.Buffer
+	rep := NewReporter(&buf)
+
+	prod := Subscription{ID: "11111111-0000-0000-0000-000000000000", DisplayName: "prod"}
+	sandbox := Subscription{ID: "22222222-0000-0000-0000-000000000000"}
+
+	rep.Notice("Resuming sync: 1 subscription(s) already synced, 2 remaining")
+	rep.Start([]Subscription{prod, sandbox})
+	rep.SubscriptionStarted(prod)
+	ok := SubscriptionResult{Subscription: prod, Resources: 12, Added: 2, Updated: 1, Removed: 3, Duration: time.Second}
+	rep.SubscriptionDone(ok)
+	rep.SubscriptionStarted(sandbox)
+	failed := SubscriptionResult{Subscription: sandbox, Err: errors.New("429 too many requests")}
+	rep.SubscriptionDone(failed)
+	rep.Finish(Summary{Results: []SubscriptionResult{ok, failed}, Duration: 2 * time.Second})
+
+	out := buf.String()
+	for _, want := range []string{
+		"Resuming sync: 1 subscription(s) already synced, 2 remaining\nSyncing 2 subscriptions",
+		"Syncing subscription: prod (11111111-0000-0000-0000-000000000000)",
+		"Synced 12 resources in 1s (+2 ~1 -3)",
+		"1 subscription(s) failed:",
+		"22222222-0000-0000-0000-000000000000 (22222222-0000-0000-0000-000000000000): 429 too many requests",
+		"Total resources cached: 12",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("plain reporter must not emit escape sequences:\n%s", out)
+	}
+}
+
+func TestSummaryOutcome(t *testing.T) {
+	ok := SubscriptionResult{Subscription: Subscription{ID: "11111111-0000-0000-0000-000000000000"}, Resources: 12}
+	tests := []struct {
+		name    string
+		summary Summary
+		want    string
+	}{
+		{"completed", Summary{Results: []SubscriptionResult{ok}, Duration: 2 * time.Second}, "Sync completed in 2s. Total resources cached: 12"},
+		{"cancelled", Summary{Results: []SubscriptionResult{ok}, Duration: 2 * time.Second, Err: context.Canceled}, "Sync cancelled after 2s. Resources cached by this run: 12"},
+		{"failed", Summary{Results: []SubscriptionResult{ok}, Duration: 2 * time.Second, Err: errors.New("disk full")}, "Sync failed after 2s. Resources cached by this run: 12"},
+		{"resumed", Summary{Results: []SubscriptionResult{ok}, Duration: 2 * time.Second, Resumed: 3}, "Resumed sync completed in 2s. Resources cached by this run: 12 (3 subscription(s) were synced before resuming)"},
+		{"nothing left", Summary{Resumed: 3}, "Nothing left to resume; 3 subscription(s) were already synced."},
+		{"cancelled early", Summary{Err: context.Canceled}, "Sync cancelled after 0s. Resources cached by this run: 0"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		writeSummary(&buf, tt.summary)
+		if out := buf.String(); !strings.Contains(out, tt.want) {
+			t.Errorf("%s: expected output to contain %q, got:\n%s", tt.name, tt.want, out)
+		}
+		if tt.name != "completed" && strings.Contains(buf.String(), "Sync completed") {
+			t.Errorf("%s: should not report a completed sync:\n%s", tt.name, buf.String())
+		}
+	}
+}
+
+func TestTTYReporterFitsTerminalWidth(t *testing.T) {
+	var buf bytes.Buffer
+	rep := newTTYReporter(&buf, func() (int, int) { return 50, 24 })
+
+	sub := Subscription{ID: "sub1", DisplayName: "a subscription with a rather long display name"}
+	rep.Start([]Subscription{sub})
+	rep.SubscriptionDone(SubscriptionResult{Subscription: sub, Resources: maxResources, Truncated: true, Duration: time.Second})
+	rep.Finish(Summary{})
+
+	for _, line := range strings.Split(buf.String(), "\n") {
+		// Each redraw starts with a carriage return; keep what follows the last.
+		if i := strings.LastIndex(line, "\r\x1b[2K"); i >= 0 {
+			line = ansi.Strip(line[i:])
+			if w := ansi.StringWidth(line); w > 50 {
+				t.Errorf("line of %d cells exceeds the terminal width: %q", w, line)
+			}
+		}
+	}
+}
+
+func TestTTYReporterFallsBackWhenTooTall(t *testing.T) {
+	var buf bytes.Buffer
+	rep := newTTYReporter(&buf, func() (int, int) { return 120, 3 })
+
+	subs := []Subscription{{ID: "sub1"}, {ID: "sub2"}, {ID: "sub3"}}
+	rep.Start(subs)
+	for _, s := range subs {
+		rep.SubscriptionStarted(s)
+		rep.SubscriptionDone(SubscriptionResult{Subscription: s, Resources: 1})
+	}
+	rep.Finish(Summary{})
+
+	out := buf.String()
+	if strings.Contains(out, "\x1b[") || !strings.Contains(out, "Syncing subscription: sub3") {
+		t.Fatalf("expected plain output for more subscriptions than rows, got:\n%s", out)
+	}
+}
@@ -0,0 +1,193 @@
+package syncer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chege/azfind/internal/display"
+)
+
+// ttyRefresh is how often running subscriptions redraw their elapsed time.
+const ttyRefresh = 200 * time.Millisecond
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+type ttyLine struct {
+	sub     Subscription
+	started time.Time
+	result  *SubscriptionResult
+}
+
+// ttyReporter keeps one line per subscription and redraws them in place.
+// Lines are cut to the terminal width so none wraps, which would throw off
+// the cursor movement of the redraw. If the subscriptions do not fit the
+// terminal's height it writes plain lines instead.
+type ttyReporter struct {
+	w io.Writer
+	// size returns the terminal's width and height, or zeros if unknown.
+	size func() (width, height int)
+	// plain takes over all events when set.
+	plain *plainReporter
+
+	mu    sync.Mutex
+	lines []*ttyLine
+	index map[string]int
+	drawn int
+	frame int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newTTYReporter(w io.Writer, size func() (width, height int)) *ttyReporter {
+	return &ttyReporter{w: w, size: size, index: map[string]int{}}
+}
+
+func (t *ttyReporter) Start(subs []Subscription) {
+	// Keep a row for the cursor below the lines.
+	if _, height := t.size(); height > 0 && len(subs) >= height {
+		t.plain = &plainReporter{w: t.w}
+		t.plain.Start(subs)
+		return
+	}
+
+	t.mu.Lock()
+	for i, s := range subs {
+		t.lines = append(t.lines, &ttyLine{sub: s})
+		t.index[s.ID] = i
+	}
+	t.redraw()
+	t.mu.Unlock()
+
+	t.stop = make(chan struct{})
+	t.done = make(chan struct{})
+	go t.tick()
+}
+
+func (t *ttyReporter) tick() {
+	defer close(t.done)
+	ticker := time.NewTicker(ttyRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			t.frame++
+			t.redraw()
+			t.mu.Unlock()
+		}
+	}
+}
+
+func (t *ttyReporter) SubscriptionStarted(sub Subscription) {
+	if t.plain != nil {
+		t.plain.SubscriptionStarted(sub)
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if i, ok := t.index[sub.ID]; ok {
+		t.lines[i].started = time.Now()
+		t.lines[i].result = nil
+	}
+	t.redraw()
+}
+
+func (t *ttyReporter) SubscriptionDone(r SubscriptionResult) {
+	if t.plain != nil {
+		t.plain.SubscriptionDone(r)
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if i, ok := t.index[r.ID]; ok {
+		t.lines[i].result = &r
+	}
+	t.redraw()
+}
+
+// Notice prints msg above the subscription lines.
+func (t *ttyReporter) Notice(msg string) {
+	if t.plain != nil {
+		t.plain.Notice(msg)
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.drawn > 0 {
+		_, _ = fmt.Fprintf(t.w, "\x1b[%dA\x1b[J", t.drawn)
+		t.drawn = 0
+	}
+	_, _ = fmt.Fprintln(t.w, msg)
+	if len(t.lines) > 0 {
+		t.redraw()
+	}
+}
+
+func (t *ttyReporter) Finish(s Summary) {
+	if t.plain != nil {
+		t.plain.Finish(s)
+		return
+	}
+	if t.stop != nil {
+		close(t.stop)
+		<-t.done
+	}
+
+	t.mu.Lock()
+	t.redraw()
+	t.mu.Unlock()
+
+	writeSummary(t.w, s)
+}
+
+// redraw moves the cursor back over the previously drawn lines and rewrites
+// them. Callers must hold t.mu.
+func (t *ttyReporter) redraw() {
+	var b strings.Builder
+	if t.drawn > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", t.drawn)
+	}
+	width, _ := t.size()
+	for _, l := range t.lines {
+		mark, text := t.render(l)
+		if width > 0 {
+			// The mark and its space take two cells; the last column stays
+			// free as some terminals wrap as soon as it is written.
+			text = display.Truncate(text, max(width-3, 0), display.TruncateEnd)
+		}
+		b.WriteString("\r\x1b[2K")
+		b.WriteString(mark + " " + text)
+		b.WriteByte('\n')
+	}
+	t.drawn = len(t.lines)
+	_, _ = io.WriteString(t.w, b.String())
+}
+
+// render returns the coloured status mark of l and the rest of its line.
+func (t *ttyReporter) render(l *ttyLine) (mark, text string) {
+	name := display.Pad(display.Truncate(l.sub.Label(), 40, display.TruncateEnd), 40)
+	switch {
+	case l.result != nil && l.result.Err != nil:
+		return "\x1b[31m✗\x1b[0m", fmt.Sprintf("%s %8s  %s", name, formatDuration(l.result.Duration), oneLine(l.result.Err.Error()))
+	case l.result != nil && l.result.Truncated:
+		return "\x1b[33m!\x1b[0m", fmt.Sprintf("%s %8s  %d resources (%s); %s", name, formatDuration(l.result.Duration), l.result.Resources, formatChanges(*l.result), truncatedWarning)
+	case l.result != nil:
+		return "\x1b[32m✓\x1b[0m", fmt.Sprintf("%s %8s  %d resources (%s)", name, formatDuration(l.result.Duration), l.result.Resources, formatChanges(*l.result))
+	case !l.started.IsZero():
+		spin := spinnerFrames[t.frame%len(spinnerFrames)]
+		return "\x1b[36m" + spin + "\x1b[0m", fmt.Sprintf("%s %8s  syncing...", name, formatDuration(time.Since(l.started)))
+	default:
+		return "\x1b[2m·\x1b[0m", fmt.Sprintf("%s %8s  pending", name, "")
+	}
+}
+
+// oneLine collapses s onto a single line so it cannot break the redraw.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,87 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/chege/azfind/internal/cache"
+	"github.com/chege/azfind/internal/display"
+	"github.com/rodaine/table"
+)
+
+// PrintStatus writes the last run and the last successful sync of every
+// subscription to w.
+func PrintStatus(ctx context.Context, w io.Writer) (err error) {
+	db, err := cache.Open(ctx)
+	if err != nil {
+		return fmt.Errorf("open cache: %w", err)
+	}
+	defer func() {
+		if cerr := db.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close cache db: %w", cerr)
+		}
+	}()
+
+	run, err := db.LastSyncRun(ctx)
+	if err != nil {
+		return err
+	}
+	statuses, err := db.SubscriptionStatuses(ctx)
+	if err != nil {
+		return err
+	}
+
+	if run == nil && len(statuses) == 0 {
+		_, _ = fmt.Fprintln(w, "No sync has run yet. Run `azf sync` first.")
+		return nil
+	}
+
+	if run != nil {
+		_, _ = fmt.Fprintln(w, describeRun(*run))
+	}
+	if len(statuses) == 0 {
+		return nil
+	}
+
+	_, _ = fmt.Fprintln(w)
+	tbl := table.New("Subscription", "Last success", "Age", "Resources", "Status").WithWriter(w)
+	for _, st := range statuses {
+		label := st.DisplayName
+		if label == "" {
+			label = st.SubscriptionID
+		}
+
+		lastSuccess, age := "never", "-"
+		if !st.LastSuccess.IsZero() {
+			lastSuccess = st.LastSuccess.Local().Format("2006-01-02 15:04")
+			age = display.Age(time.Since(st.LastSuccess))
+		}
+
+		status := "ok"
+		if st.LastErr != "" {
+			status = "failed: " + display.Truncate(oneLine(st.LastErr), 60, display.TruncateEnd)
+		}
+		tbl.AddRow(label, lastSuccess, age, st.Resources, status)
+	}
+	tbl.Print()
+	return nil
+}
+
+func describeRun(run cache.SyncRun) string {
+	started := fmt.Sprintf("%s (%s ago)", run.StartedAt.Local().Format("2006-01-02 15:04"), display.Age(time.Since(run.StartedAt)))
+	switch {
+	case run.Status == cache.RunRunning && Running():
+		return fmt.Sprintf("Sync in progress, started %s", started)
+	case run.Status == cache.RunRunning:
+		return fmt.Sprintf("Last sync started %s and did not finish; run `azf sync --resume` to continue", started)
+	case run.Status == cache.RunCancelled:
+		return fmt.Sprintf("Last sync started %s was cancelled after %d subscriptions; run `azf sync --resume` to continue", started, run.Subscriptions)
+	case run.Err != "":
+		return fmt.Sprintf("Last sync started %s failed: %s", started, run.Err)
+	default:
+		return fmt.Sprintf("Last sync started %s took %s: %d subscriptions, %d failed, %d resources",
+			started, formatDuration(run.FinishedAt.Sub(run.StartedAt)), run.Subscriptions, run.Failed, run.Resources)
+	}
+}
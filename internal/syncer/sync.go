@@ -2,14 +2,42 @@ package syncer
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
 	"github.com/chege/azfind/internal/azure"
 	"github.com/chege/azfind/internal/cache"
 )
 
-func SyncAll(ctx context.Context) error {
+// Options controls a sync run.
+type Options struct {
+	// Resume continues the last cancelled or failed run, skipping the
+	// subscriptions it already synced successfully.
+	Resume bool
+	// Retry tunes retries of individual Azure requests.
+	Retry azure.RetryConfig
+	// RetryFailed syncs subscriptions that failed once more at the end of the run.
+	RetryFailed bool
+}
+
+// SyncAll refreshes the cache from every accessible subscription, reporting
+// progress to rep. A subscription that fails to list is recorded in the
+// summary and does not stop the run. When ctx is cancelled the subscription in
+// flight is rolled back, completed ones stay recorded and the run can be
+// continued with Options.Resume.
+func SyncAll(ctx context.Context, rep Reporter, opts Options) error {
+	release, err := acquireLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// Step 1: Authenticate
 	cred, err := azure.GetCredential()
 	if err != nil {
@@ -21,57 +49,390 @@ func SyncAll(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to open cache: %w", err)
 	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	run, done, err := beginRun(ctx, db, rep, opts)
+	if err != nil {
+		return err
+	}
 
 	// Step 3: List subscriptions
-	subs, err := azure.ListSubscriptions(ctx, cred)
+	clientOpts := opts.Retry.ClientOptions()
+	azSubs, err := azure.ListSubscriptions(ctx, cred, clientOpts)
 	if err != nil {
-		return fmt.Errorf("failed to list subscriptions: %w", err)
+		err = fmt.Errorf("failed to list subscriptions: %w", err)
+		return finishRun(ctx, db, run, err)
 	}
-	if len(subs) == 0 {
-		fmt.Println("No subscriptions found.")
-		return nil
+	cached := cacheSubscriptions(azSubs)
+	// Like tenants, the management group tree is not needed to sync resources.
+	if err := syncManagementGroups(ctx, db, cred, clientOpts, cached); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if err := db.ReplaceSubscriptions(ctx, cached); err != nil {
+		err = fmt.Errorf("failed to store subscriptions: %w", err)
+		return finishRun(ctx, db, run, err)
+	}
+	// Tenant names are cosmetic, so failing to list them does not stop the run.
+	if err := syncTenants(ctx, db, cred, clientOpts); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: %v\n", err)
 	}
 
-	total := 0
-	for _, sub := range subs {
+	var subs []Subscription
+	skipped := 0
+	for _, sub := range azSubs {
 		if sub == nil || sub.SubscriptionID == nil {
 			continue
 		}
+		if done[*sub.SubscriptionID] {
+			skipped++
+			continue
+		}
+		s := Subscription{ID: *sub.SubscriptionID}
+		if sub.DisplayName != nil {
+			s.DisplayName = *sub.DisplayName
+		}
+		subs = append(subs, s)
+	}
+	if skipped > 0 {
+		rep.Notice(fmt.Sprintf("Resuming sync: %d subscription(s) already synced, %d remaining", skipped, len(subs)))
+	}
 
-		subID := *sub.SubscriptionID
-		fmt.Printf("Syncing subscription: %s\n", subID)
+	start := time.Now()
+	rep.Start(subs)
 
-		resList, err := azure.ListResources(ctx, cred, subID, 5000)
+	// Step 4: Sync each subscription
+	summary := Summary{Resumed: skipped}
+	finish := func(err error) error {
+		summary.Duration = time.Since(start)
+		summary.Err = err
+		rep.Finish(summary)
+		return finishRun(ctx, db, run, err)
+	}
+	syncOne := func(sub Subscription) (SubscriptionResult, error) {
+		rep.SubscriptionStarted(sub)
+		result, err := syncSubscription(ctx, db, cred, clientOpts, sub)
+		if ctx.Err() != nil {
+			// The in-flight subscription was rolled back; leave it for --resume.
+			return result, ctx.Err()
+		}
 		if err != nil {
-			log.Printf("warning: failed to list resources for %s: %v\n", subID, err)
-			continue
+			return result, err
 		}
+		if err := db.RecordSubscriptionSync(ctx, subscriptionRecord(run.ID, result)); err != nil {
+			return result, err
+		}
+		rep.SubscriptionDone(result)
+		return result, nil
+	}
 
-		resources := make([]cache.Resource, 0, len(resList))
-		for _, r := range resList {
-			resources = append(resources, cache.Resource{
-				ID:             fmt.Sprintf("%v", r["id"]),
-				Name:           fmt.Sprintf("%v", r["name"]),
-				Type:           fmt.Sprintf("%v", r["type"]),
-				SubscriptionID: fmt.Sprintf("%v", r["subscriptionId"]),
-				ResourceGroup:  fmt.Sprintf("%v", r["resourceGroup"]),
-				Location:       fmt.Sprintf("%v", r["location"]),
-				TenantID:       fmt.Sprintf("%v", r["tenantId"]),
-			})
+	for _, sub := range subs {
+		if ctx.Err() != nil {
+			return finish(ctx.Err())
 		}
+		result, err := syncOne(sub)
+		if err != nil {
+			return finish(err)
+		}
+		summary.Results = append(summary.Results, result)
+	}
 
-		if err := db.InsertResources(ctx, resources); err != nil {
-			return fmt.Errorf("sync: failed to insert resources for subscription %s: %w", subID, err)
+	// Step 5: Give failed subscriptions a second chance; throttling often
+	// clears up once the rest of the run is done.
+	if opts.RetryFailed {
+		for i, prev := range summary.Results {
+			if prev.Err == nil {
+				continue
+			}
+			if ctx.Err() != nil {
+				return finish(ctx.Err())
+			}
+			result, err := syncOne(prev.Subscription)
+			if err != nil {
+				return finish(err)
+			}
+			summary.Results[i] = result
 		}
+	}
 
-		total += len(resources)
-		fmt.Printf("  → Synced %d resources\n", len(resources))
+	if err := finish(nil); err != nil {
+		return err
 	}
 
 	if err := db.Close(); err != nil {
 		return fmt.Errorf("failed to close cache db: %w", err)
 	}
+	return nil
+}
+
+// beginRun starts a new run, or reopens the last one when resuming, and
+// returns the subscriptions that need no syncing.
+func beginRun(ctx context.Context, db *cache.DB, rep Reporter, opts Options) (cache.SyncRun, map[string]bool, error) {
+	if opts.Resume {
+		last, err := db.LastSyncRun(ctx)
+		if err != nil {
+			return cache.SyncRun{}, nil, err
+		}
+		if last != nil && last.Resumable() {
+			done, err := db.CompletedSubscriptions(ctx, last.ID)
+			if err != nil {
+				return cache.SyncRun{}, nil, err
+			}
+			if err := db.ReopenSyncRun(ctx, last.ID); err != nil {
+				return cache.SyncRun{}, nil, err
+			}
+			return *last, done, nil
+		}
+		rep.Notice("Nothing to resume; running a full sync.")
+	}
+
+	id, err := db.BeginSyncRun(ctx, time.Now())
+	if err != nil {
+		return cache.SyncRun{}, nil, err
+	}
+	return cache.SyncRun{ID: id}, nil, nil
+}
+
+// finishRun records how the run ended and returns err, wrapped when the run
+// was cancelled. Bookkeeping uses a context that survives cancellation so an
+// interrupted run is still recorded.
+func finishRun(ctx context.Context, db *cache.DB, run cache.SyncRun, err error) error {
+	run.FinishedAt = time.Now()
+	switch {
+	case err == nil:
+		run.Status = cache.RunCompleted
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		run.Status = cache.RunCancelled
+		run.Err = err.Error()
+		err = fmt.Errorf("sync cancelled; run `azf sync --resume` to continue: %w", err)
+	default:
+		run.Status = cache.RunFailed
+		run.Err = err.Error()
+	}
+
+	if ferr := db.FinishSyncRun(context.WithoutCancel(ctx), run); ferr != nil && err == nil {
+		return ferr
+	}
+	return err
+}
+
+func subscriptionRecord(runID int64, r SubscriptionResult) cache.SubscriptionSync {
+	rec := cache.SubscriptionSync{
+		RunID:          runID,
+		SubscriptionID: r.ID,
+		DisplayName:    r.DisplayName,
+		StartedAt:      r.Started,
+		FinishedAt:     r.Started.Add(r.Duration),
+		Duration:       r.Duration,
+		Resources:      r.Resources,
+		Added:          r.Added,
+		Updated:        r.Updated,
+		Removed:        r.Removed,
+	}
+	if r.Err != nil {
+		rec.Err = r.Err.Error()
+	}
+	return rec
+}
+
+// maxResources caps the resources listed per subscription. A listing that
+// reaches it is treated as truncated.
+const maxResources = 5000
+
+// syncSubscription lists the resources of one subscription and replaces its
+// cached rows. A truncated listing only adds and refreshes rows, since the
+// missing ones may still exist. Listing failures are returned in the result;
+// only cache write failures are returned as an error since they affect the
+// whole run.
+func syncSubscription(ctx context.Context, db *cache.DB, cred azcore.TokenCredential, clientOpts *arm.ClientOptions, sub Subscription) (SubscriptionResult, error) {
+	start := time.Now()
+	result := SubscriptionResult{Subscription: sub, Started: start}
+
+	resList, err := azure.ListResources(ctx, cred, sub.ID, maxResources, clientOpts)
+	if err != nil {
+		result.Err = fmt.Errorf("list resources: %w", err)
+		result.Duration = time.Since(start)
+		return result, nil
+	}
+
+	resources := make([]cache.Resource, 0, len(resList))
+	for _, r := range resList {
+		resources = append(resources, cache.Resource{
+			ID:             fmt.Sprintf("%v", r["id"]),
+			Name:           fmt.Sprintf("%v", r["name"]),
+			Type:           fmt.Sprintf("%v", r["type"]),
+			SubscriptionID: fmt.Sprintf("%v", r["subscriptionId"]),
+			ResourceGroup:  fmt.Sprintf("%v", r["resourceGroup"]),
+			Location:       fmt.Sprintf("%v", r["location"]),
+			TenantID:       fmt.Sprintf("%v", r["tenantId"]),
+			SKU:            skuName(r["sku"]),
+			Tags:           tags(r["tags"]),
+		})
+	}
+
+	store := db.ReplaceSubscriptionResources
+	if len(resources) >= maxResources {
+		result.Truncated = true
+		store = db.MergeSubscriptionResources
+	}
+	stats, err := store(ctx, sub.ID, resources)
+	if err != nil {
+		return result, fmt.Errorf("sync: failed to store resources for subscription %s: %w", sub.ID, err)
+	}
+
+	result.Resources = len(resources)
+	result.Added = stats.Added
+	result.Updated = stats.Updated
+	result.Removed = stats.Removed
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// skuName formats the sku column of a Resource Graph row, e.g. "Standard_LRS"
+// or "S1 (Standard)". Resources without a SKU give "".
+func skuName(v any) string {
+	sku, ok := v.(map[string]any)
+	if !ok {
+		return ""
+	}
+	name, _ := sku["name"].(string)
+	tier, _ := sku["tier"].(string)
+	switch {
+	case name == "":
+		return tier
+	case tier == "" || strings.EqualFold(tier, name):
+		return name
+	default:
+		return fmt.Sprintf("%s (%s)", name, tier)
+	}
+}
+
+// tags converts the tags column of a Resource Graph row.
+func tags(v any) map[string]string {
+	raw, ok := v.(map[string]any)
+	if !ok || len(raw) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(raw))
+	for k, val := range raw {
+		out[k] = fmt.Sprint(val)
+	}
+	return out
+}
 
-	fmt.Printf("Sync completed. Total resources cached: %d\n", total)
+// cacheSubscriptions converts listed subscriptions for the cache.
+func cacheSubscriptions(azSubs []*armsubscriptions.Subscription) []cache.Subscription {
+	subs := make([]cache.Subscription, 0, len(azSubs))
+	for _, sub := range azSubs {
+		if sub == nil || sub.SubscriptionID == nil {
+			continue
+		}
+		s := cache.Subscription{ID: *sub.SubscriptionID}
+		if sub.DisplayName != nil {
+			s.DisplayName = *sub.DisplayName
+		}
+		if sub.State != nil {
+			s.State = string(*sub.State)
+		}
+		if sub.TenantID != nil {
+			s.TenantID = *sub.TenantID
+		}
+		for k, v := range sub.Tags {
+			if v == nil {
+				continue
+			}
+			if s.Tags == nil {
+				s.Tags = map[string]string{}
+			}
+			s.Tags[k] = *v
+		}
+		subs = append(subs, s)
+	}
+	return subs
+}
+
+// syncTenants replaces the cached tenants with those the credential can see.
+func syncTenants(ctx context.Context, db *cache.DB, cred azcore.TokenCredential, clientOpts *arm.ClientOptions) error {
+	azTenants, err := azure.ListTenants(ctx, cred, clientOpts)
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+	if err := db.ReplaceTenants(ctx, cacheTenants(azTenants)); err != nil {
+		return fmt.Errorf("failed to store tenants: %w", err)
+	}
 	return nil
 }
+
+// cacheTenants converts listed tenants for the cache.
+func cacheTenants(azTenants []*armsubscriptions.TenantIDDescription) []cache.Tenant {
+	tenants := make([]cache.Tenant, 0, len(azTenants))
+	for _, t := range azTenants {
+		if t == nil || t.TenantID == nil {
+			continue
+		}
+		tenant := cache.Tenant{ID: *t.TenantID}
+		if t.DisplayName != nil {
+			tenant.DisplayName = *t.DisplayName
+		}
+		if t.DefaultDomain != nil {
+			tenant.DefaultDomain = *t.DefaultDomain
+		}
+		tenants = append(tenants, tenant)
+	}
+	return tenants
+}
+
+// syncManagementGroups replaces the cached management group tree and sets
+// the management group of each of subs. If the tree cannot be listed, subs
+// keep the management groups they had in the cache.
+func syncManagementGroups(ctx context.Context, db *cache.DB, cred azcore.TokenCredential, clientOpts *arm.ClientOptions, subs []cache.Subscription) error {
+	rows, err := azure.ListManagementGroups(ctx, cred, clientOpts)
+	if err != nil {
+		if prev, perr := db.ListSubscriptions(ctx); perr == nil {
+			parents := map[string]string{}
+			for _, s := range prev {
+				parents[strings.ToLower(s.ID)] = s.ManagementGroupID
+			}
+			for i := range subs {
+				subs[i].ManagementGroupID = parents[strings.ToLower(subs[i].ID)]
+			}
+		}
+		return fmt.Errorf("failed to list management groups: %w", err)
+	}
+
+	groups, parents := managementGroups(rows)
+	for i := range subs {
+		subs[i].ManagementGroupID = parents[strings.ToLower(subs[i].ID)]
+	}
+	if err := db.ReplaceManagementGroups(ctx, groups); err != nil {
+		return fmt.Errorf("failed to store management groups: %w", err)
+	}
+	return nil
+}
+
+// managementGroups splits the rows of azure.ListManagementGroups into the
+// management groups and the parent group of each subscription, keyed by
+// lower-case subscription ID.
+func managementGroups(rows []map[string]any) ([]cache.ManagementGroup, map[string]string) {
+	var groups []cache.ManagementGroup
+	parents := map[string]string{}
+	for _, r := range rows {
+		typ, _ := r["type"].(string)
+		parent, _ := r["parent"].(string)
+		switch strings.ToLower(typ) {
+		case "microsoft.management/managementgroups":
+			name, _ := r["name"].(string)
+			if name == "" {
+				continue
+			}
+			displayName, _ := r["displayName"].(string)
+			groups = append(groups, cache.ManagementGroup{ID: name, DisplayName: displayName, ParentID: parent})
+		case "microsoft.resources/subscriptions":
+			if id, _ := r["subscriptionId"].(string); id != "" && parent != "" {
+				parents[strings.ToLower(id)] = parent
+			}
+		}
+	}
+	return groups, parents
+}
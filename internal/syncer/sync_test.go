@@ -0,0 +1,213 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"github.com/chege/azfind/internal/azure"
+	"github.com/chege/azfind/internal/cache"
+)
+
+func TestSkuName(t *testing.T) {
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{nil, ""},
+		{map[string]any{"name": "Standard_LRS", "tier": "Standard"}, "Standard_LRS (Standard)"},
+		{map[string]any{"name": "standard", "tier": "Standard"}, "standard"},
+		{map[string]any{"tier": "Basic"}, "Basic"},
+	}
+	for _, tt := range tests {
+		if got := skuName(tt.in); got != tt.want {
+			t.Errorf("skuName(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	got := tags(map[string]any{"env": "prod", "cost": 42.0})
+	if len(got) != 2 || got["env"] != "prod" || got["cost"] != "42" {
+		t.Fatalf("unexpected tags: %v", got)
+	}
+	if tags(nil) != nil || tags(map[string]any{}) != nil {
+		t.Fatal("expected no tags for empty input")
+	}
+}
+
+func TestCacheSubscriptions(t *testing.T) {
+	state := armsubscriptions.SubscriptionStateEnabled
+	got := cacheSubscriptions([]*armsubscriptions.Subscription{
+		nil,
+		{DisplayName: to.Ptr("no id")},
+		{
+			SubscriptionID: to.Ptr("sub1"), DisplayName: to.Ptr("Production"), State: &state,
+			TenantID: to.Ptr("tenant1"), Tags: map[string]*string{"env": to.Ptr("prod"), "empty": nil},
+		},
+	})
+	if len(got) != 1 {
+		t.Fatalf("expected one subscription, got %+v", got)
+	}
+	s := got[0]
+	if s.ID != "sub1" || s.DisplayName != "Production" || s.State != "Enabled" || s.TenantID != "tenant1" {
+		t.Fatalf("unexpected subscription: %+v", s)
+	}
+	if len(s.Tags) != 1 || s.Tags["env"] != "prod" {
+		t.Fatalf("unexpected tags: %v", s.Tags)
+	}
+}
+
+func TestCacheTenants(t *testing.T) {
+	got := cacheTenants([]*armsubscriptions.TenantIDDescription{
+		nil,
+		{DisplayName: to.Ptr("no id")},
+		{TenantID: to.Ptr("t1"), DisplayName: to.Ptr("Contoso"), DefaultDomain: to.Ptr("contoso.onmicrosoft.com")},
+	})
+	if len(got) != 1 || got[0].ID != "t1" || got[0].DisplayName != "Contoso" || got[0].DefaultDomain != "contoso.onmicrosoft.com" {
+		t.Fatalf("unexpected tenants: %+v", got)
+	}
+}
+
+func TestManagementGroups(t *testing.T) {
+	groups, parents := managementGroups([]map[string]any{
+		{"type": "microsoft.management/managementgroups", "name": "root", "displayName": "Tenant Root Group", "parent": ""},
+		{"type": "Microsoft.Management/managementGroups", "name": "corp", "displayName": "Corp", "parent": "root"},
+		{"type": "microsoft.resources/subscriptions", "subscriptionId": "SUB1", "parent": "corp"},
+		{"type": "microsoft.resources/subscriptions", "subscriptionId": "sub2", "parent": ""},
+	})
+	if len(groups) != 2 || groups[1].ID != "corp" || groups[1].ParentID != "root" || groups[0].DisplayName != "Tenant Root Group" {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+	if len(parents) != 1 || parents["sub1"] != "corp" {
+		t.Fatalf("unexpected parents: %v", parents)
+	}
+}
+
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "fake", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestSyncSubscriptionTruncated(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	db, err := cache.Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	const unlisted = "/subscriptions/sub1/resourceGroups/rg/providers/Microsoft.Web/sites/zz-unlisted"
+	if err := db.InsertResources(ctx, []cache.Resource{{ID: unlisted, Name: "zz-unlisted", SubscriptionID: "sub1"}}); err != nil {
+		t.Fatalf("failed to insert resources: %v", err)
+	}
+
+	rows := 1
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := make([]map[string]any, rows)
+		for i := range data {
+			data[i] = map[string]any{
+				"id":             fmt.Sprintf("/subscriptions/sub1/resourceGroups/rg/providers/Microsoft.Web/sites/app%04d", i),
+				"name":           fmt.Sprintf("app%04d", i),
+				"subscriptionId": "sub1",
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"totalRecords": rows, "count": rows, "data": data})
+	}))
+	defer srv.Close()
+
+	opts := azure.RetryConfig{}.ClientOptions()
+	opts.Cloud = cloud.Configuration{
+		ActiveDirectoryAuthorityHost: srv.URL,
+		Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+			cloud.ResourceManager: {Audience: "https://management.core.windows.net/", Endpoint: srv.URL},
+		},
+	}
+	opts.Transport = srv.Client()
+	sub := Subscription{ID: "sub1"}
+
+	// A listing that reaches the cap may be missing resources, so none are removed.
+	rows = maxResources
+	result, err := syncSubscription(ctx, db, fakeCredential{}, opts, sub)
+	if err != nil || result.Err != nil {
+		t.Fatalf("sync failed: %v, %v", err, result.Err)
+	}
+	if !result.Truncated || result.Added != maxResources || result.Removed != 0 {
+		t.Fatalf("unexpected result for a truncated listing: %+v", result)
+	}
+	if r, err := db.FindResourceByID(ctx, unlisted); err != nil || r == nil {
+		t.Fatalf("expected the unlisted resource to be kept, got %+v, %v", r, err)
+	}
+
+	rows = 1
+	result, err = syncSubscription(ctx, db, fakeCredential{}, opts, sub)
+	if err != nil || result.Err != nil {
+		t.Fatalf("sync failed: %v, %v", err, result.Err)
+	}
+	if result.Truncated || result.Removed != maxResources {
+		t.Fatalf("unexpected result for a complete listing: %+v", result)
+	}
+}
+
+// recordingReporter keeps the notices it is given and ignores other events.
+type recordingReporter struct {
+	notices []string
+}
+
+func (*recordingReporter) Start([]Subscription)                {}
+func (*recordingReporter) SubscriptionStarted(Subscription)    {}
+func (*recordingReporter) SubscriptionDone(SubscriptionResult) {}
+func (*recordingReporter) Finish(Summary)                      {}
+func (r *recordingReporter) Notice(msg string)                 { r.notices = append(r.notices, msg) }
+
+func TestBeginRunReportsNothingToResume(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	db, err := cache.Open(ctx)
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	rep := &recordingReporter{}
+	run, done, err := beginRun(ctx, db, rep, Options{Resume: true})
+	if err != nil {
+		t.Fatalf("begin run: %v", err)
+	}
+	if len(done) != 0 || len(rep.notices) != 1 || !strings.HasPrefix(rep.notices[0], "Nothing to resume") {
+		t.Fatalf("unexpected resume of a fresh cache: done %v, notices %q", done, rep.notices)
+	}
+
+	if err := db.RecordSubscriptionSync(ctx, cache.SubscriptionSync{RunID: run.ID, SubscriptionID: "sub1"}); err != nil {
+		t.Fatalf("record subscription: %v", err)
+	}
+	run.Status = cache.RunCancelled
+	if err := db.FinishSyncRun(ctx, run); err != nil {
+		t.Fatalf("finish run: %v", err)
+	}
+
+	rep = &recordingReporter{}
+	if _, done, err = beginRun(ctx, db, rep, Options{Resume: true}); err != nil {
+		t.Fatalf("resume run: %v", err)
+	}
+	if !done["sub1"] || len(rep.notices) != 0 {
+		t.Fatalf("unexpected resume: done %v, notices %q", done, rep.notices)
+	}
+}
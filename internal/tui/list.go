@@ -0,0 +1,377 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/chege/azfind/internal/display"
+	"github.com/chege/azfind/internal/picker"
+)
+
+// minPreviewWidth is the terminal width below which the preview pane is hidden.
+const minPreviewWidth = 80
+
+// previewPercent is the share of the terminal width taken by the preview.
+const previewPercent = 40
+
+// item is one line of a list.
+type item struct {
+	// label is the text shown in the list.
+	label string
+	// styled is label with ANSI colours, shown unless the item is
+	// highlighted. Empty means label.
+	styled string
+	// fields are searched by the query; the first one ranks highest.
+	fields []string
+}
+
+// listConfig configures a list run.
+type listConfig struct {
+	// prompt is shown before the query.
+	prompt string
+	// query pre-fills the search prompt.
+	query string
+	// header is shown above the list.
+	header string
+	// multi allows marking several items with tab.
+	multi bool
+	// keys accept the selection like enter does, in fzf notation ("ctrl-o").
+	keys []string
+	// preview returns the details of item i. It runs in the background and
+	// may be slow. Nil hides the preview pane.
+	preview func(i int) string
+}
+
+// result is what a list run ends with.
+type result struct {
+	// key is the key that accepted the selection; empty for enter.
+	key string
+	// picked holds the indices of the marked items, or of the highlighted
+	// one if none were marked.
+	picked []int
+}
+
+// styles used to draw a list.
+type styles struct {
+	prompt, header, cursor, mark, info, preview lipgloss.Style
+}
+
+func newStyles(r *lipgloss.Renderer) styles {
+	return styles{
+		prompt: r.NewStyle().Foreground(lipgloss.Color("6")).Bold(true),
+		header: r.NewStyle().Foreground(lipgloss.Color("8")),
+		cursor: r.NewStyle().Background(lipgloss.Color("236")).Bold(true),
+		mark:   r.NewStyle().Foreground(lipgloss.Color("5")),
+		info:   r.NewStyle().Foreground(lipgloss.Color("3")),
+		preview: r.NewStyle().
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderLeft(true).
+			BorderForeground(lipgloss.Color("8")).
+			PaddingLeft(1),
+	}
+}
+
+// listModel is the bubbletea model behind every list: the resource picker
+// and the action menus.
+type listModel struct {
+	items  []item
+	cfg    listConfig
+	styles styles
+
+	query   []rune
+	matches []int // indices into items, best match first
+	cursor  int   // position in matches
+	offset  int   // first visible match
+	marked  map[int]bool
+
+	previews map[int]string // rendered previews by item
+	pending  map[int]bool   // previews being rendered
+
+	width, height int
+	result        *result
+}
+
+func newList(items []item, cfg listConfig, st styles) *listModel {
+	m := &listModel{
+		items:    items,
+		cfg:      cfg,
+		styles:   st,
+		query:    []rune(cfg.query),
+		marked:   map[int]bool{},
+		previews: map[int]string{},
+		pending:  map[int]bool{},
+	}
+	m.filter()
+	return m
+}
+
+// runList shows items on the terminal and returns the user's choice, or nil
+// if they cancelled.
+func runList(items []item, cfg listConfig) (*result, error) {
+	m := newList(items, cfg, newStyles(lipgloss.NewRenderer(os.Stderr)))
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithInputTTY(), tea.WithOutput(os.Stderr))
+	final, err := p.Run()
+	if err != nil {
+		return nil, runError(err)
+	}
+	return final.(*listModel).result, nil
+}
+
+// runError wraps an error of the program. Apart from a kill or an interrupt,
+// the program only fails when it cannot set up the terminal, e.g. because
+// there is no TTY to read keys from; that makes the picker unavailable.
+func runError(err error) error {
+	if errors.Is(err, tea.ErrProgramKilled) || errors.Is(err, tea.ErrInterrupted) {
+		return fmt.Errorf("run picker: %w", err)
+	}
+	return fmt.Errorf("%w: %w", picker.ErrPickerUnavailable, err)
+}
+
+func (m *listModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *listModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.scroll()
+	case tea.KeyMsg:
+		if cmd := m.handleKey(msg); cmd != nil {
+			return m, cmd
+		}
+	case previewMsg:
+		m.previews[msg.item] = msg.text
+		delete(m.pending, msg.item)
+	}
+	return m, m.loadPreview()
+}
+
+// previewMsg carries the rendered preview of an item.
+type previewMsg struct {
+	item int
+	text string
+}
+
+// loadPreview starts rendering the preview of the highlighted item unless it
+// is already available or on its way.
+func (m *listModel) loadPreview() tea.Cmd {
+	if m.cfg.preview == nil || len(m.matches) == 0 || m.width < minPreviewWidth {
+		return nil
+	}
+	i := m.matches[m.cursor]
+	if _, ok := m.previews[i]; ok || m.pending[i] {
+		return nil
+	}
+	m.pending[i] = true
+	preview := m.cfg.preview
+	return func() tea.Msg {
+		return previewMsg{item: i, text: preview(i)}
+	}
+}
+
+func (m *listModel) handleKey(msg tea.KeyMsg) tea.Cmd {
+	key := fzfKey(msg)
+	for _, k := range m.cfg.keys {
+		if k == key {
+			return m.accept(key)
+		}
+	}
+
+	switch msg.Type {
+	case tea.KeyEnter:
+		return m.accept("")
+	case tea.KeyEsc, tea.KeyCtrlC, tea.KeyCtrlG:
+		return tea.Quit
+	case tea.KeyUp, tea.KeyCtrlP:
+		m.move(-1)
+	case tea.KeyDown, tea.KeyCtrlN:
+		m.move(1)
+	case tea.KeyPgUp:
+		m.move(-m.rows())
+	case tea.KeyPgDown:
+		m.move(m.rows())
+	case tea.KeyTab:
+		m.toggle()
+		m.move(1)
+	case tea.KeyShiftTab:
+		m.toggle()
+		m.move(-1)
+	case tea.KeyBackspace:
+		if len(m.query) > 0 {
+			m.query = m.query[:len(m.query)-1]
+			m.filter()
+		}
+	case tea.KeyCtrlW:
+		q := strings.TrimRight(string(m.query), " ")
+		m.query = []rune(q[:strings.LastIndex(q, " ")+1])
+		m.filter()
+	case tea.KeyRunes, tea.KeySpace:
+		if !msg.Alt {
+			m.query = append(m.query, msg.Runes...)
+			m.filter()
+		}
+	}
+	return nil
+}
+
+// fzfKey names a key the way fzf does, e.g. "ctrl-o" or "alt-x".
+func fzfKey(msg tea.KeyMsg) string {
+	if msg.Type == tea.KeyRunes && !msg.Alt {
+		return string(msg.Runes)
+	}
+	return strings.ReplaceAll(msg.String(), "+", "-")
+}
+
+// accept ends the run with the marked items, or the highlighted one.
+func (m *listModel) accept(key string) tea.Cmd {
+	var picked []int
+	for i := range m.items {
+		if m.marked[i] {
+			picked = append(picked, i)
+		}
+	}
+	if len(picked) == 0 {
+		if len(m.matches) == 0 {
+			return nil
+		}
+		picked = []int{m.matches[m.cursor]}
+	}
+	m.result = &result{key: key, picked: picked}
+	return tea.Quit
+}
+
+func (m *listModel) toggle() {
+	if !m.cfg.multi || len(m.matches) == 0 {
+		return
+	}
+	i := m.matches[m.cursor]
+	if m.marked[i] {
+		delete(m.marked, i)
+	} else {
+		m.marked[i] = true
+	}
+}
+
+func (m *listModel) move(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.matches) {
+		m.cursor = len(m.matches) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	m.scroll()
+}
+
+// scroll keeps the cursor within the visible rows.
+func (m *listModel) scroll() {
+	rows := m.rows()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if rows > 0 && m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+}
+
+// filter recomputes the matches for the current query, best first.
+func (m *listModel) filter() {
+	query := string(m.query)
+	scores := make(map[int]int, len(m.items))
+	m.matches = m.matches[:0]
+	for i, it := range m.items {
+		if s, ok := match(query, it.fields); ok {
+			m.matches = append(m.matches, i)
+			scores[i] = s
+		}
+	}
+	sort.SliceStable(m.matches, func(a, b int) bool {
+		return scores[m.matches[a]] > scores[m.matches[b]]
+	})
+	m.cursor, m.offset = 0, 0
+}
+
+// headerLines returns the lines above the list.
+func (m *listModel) headerLines() []string {
+	info := fmt.Sprintf("  %d/%d", len(m.matches), len(m.items))
+	if len(m.marked) > 0 {
+		info += fmt.Sprintf(" (%d marked)", len(m.marked))
+	}
+	lines := []string{
+		m.styles.prompt.Render(m.cfg.prompt) + string(m.query) + "█",
+		m.styles.info.Render(info),
+	}
+	if m.cfg.header != "" {
+		for _, l := range strings.Split(m.cfg.header, "\n") {
+			lines = append(lines, m.styles.header.Render("  "+display.Truncate(l, m.width-2, display.TruncateEnd)))
+		}
+	}
+	return lines
+}
+
+// rows is the number of list lines that fit on the screen.
+func (m *listModel) rows() int {
+	return m.height - len(m.headerLines())
+}
+
+func (m *listModel) View() string {
+	if m.width == 0 || m.height == 0 || m.result != nil {
+		return ""
+	}
+
+	rows := m.rows()
+	if rows < 1 {
+		return strings.Join(m.headerLines(), "\n")
+	}
+
+	listWidth := m.width
+	showPreview := m.cfg.preview != nil && m.width >= minPreviewWidth
+	if showPreview {
+		listWidth = m.width * (100 - previewPercent) / 100
+	}
+
+	lines := make([]string, 0, rows)
+	for pos := m.offset; pos < len(m.matches) && pos < m.offset+rows; pos++ {
+		i := m.matches[pos]
+		mark := " "
+		if m.marked[i] {
+			mark = "●"
+		}
+		text := m.items[i].label
+		if pos != m.cursor && m.items[i].styled != "" {
+			text = m.items[i].styled
+		}
+		label := ansi.Truncate(text, listWidth-3, "...")
+		label += strings.Repeat(" ", max(listWidth-3-ansi.StringWidth(label), 0))
+		if pos == m.cursor {
+			lines = append(lines, m.styles.cursor.Render(">"+mark+" "+label))
+		} else {
+			lines = append(lines, " "+m.styles.mark.Render(mark)+" "+label)
+		}
+	}
+	list := lipgloss.NewStyle().Width(listWidth).Height(rows).MaxHeight(rows).Render(strings.Join(lines, "\n"))
+
+	body := list
+	if showPreview && len(m.matches) > 0 {
+		text, ok := m.previews[m.matches[m.cursor]]
+		if !ok {
+			text = m.styles.header.Render("loading...")
+		}
+		// Width covers the padding but not the border.
+		preview := m.styles.preview.
+			Width(m.width - listWidth - 1).
+			Height(rows).
+			MaxHeight(rows).
+			Render(text)
+		body = lipgloss.JoinHorizontal(lipgloss.Top, list, preview)
+	}
+
+	return strings.Join(append(m.headerLines(), body), "\n")
+}
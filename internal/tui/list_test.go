@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/chege/azfind/internal/picker"
+)
+
+func testList(cfg listConfig) *listModel {
+	items := []item{
+		{label: "kubevault", fields: []string{"kubevault"}},
+		{label: "kv-prod", fields: []string{"kv-prod"}},
+		{label: "web-prod", fields: []string{"web-prod"}},
+	}
+	m := newList(items, cfg, newStyles(lipgloss.NewRenderer(nil)))
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	return m
+}
+
+func typeQuery(m *listModel, q string) {
+	for _, r := range q {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestListFiltersAndRanks(t *testing.T) {
+	m := testList(listConfig{})
+	if len(m.matches) != 3 {
+		t.Fatalf("expected all items without a query, got %v", m.matches)
+	}
+
+	typeQuery(m, "kv")
+	if len(m.matches) != 2 || m.matches[0] != 1 {
+		t.Fatalf("expected kv-prod first of two matches, got %v", m.matches)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if string(m.query) != "" || len(m.matches) != 3 {
+		t.Fatalf("expected backspace to clear the query, got %q with %v", string(m.query), m.matches)
+	}
+}
+
+func TestListEnterPicksHighlighted(t *testing.T) {
+	m := testList(listConfig{query: "web"})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil || m.result == nil {
+		t.Fatalf("expected enter to finish the list")
+	}
+	if m.result.key != "" || len(m.result.picked) != 1 || m.result.picked[0] != 2 {
+		t.Fatalf("expected web-prod picked with enter, got %+v", m.result)
+	}
+}
+
+func TestListMarksAndAcceptKeys(t *testing.T) {
+	m := testList(listConfig{multi: true, keys: []string{"ctrl-o"}})
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+
+	if m.result == nil || m.result.key != "ctrl-o" {
+		t.Fatalf("expected ctrl-o to finish the list, got %+v", m.result)
+	}
+	if len(m.result.picked) != 2 || m.result.picked[0] != 0 || m.result.picked[1] != 2 {
+		t.Fatalf("expected items 0 and 2 marked, got %v", m.result.picked)
+	}
+}
+
+func TestListEscCancels(t *testing.T) {
+	m := testList(listConfig{})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd == nil || m.result != nil {
+		t.Fatalf("expected esc to quit without a result, got %+v", m.result)
+	}
+}
+
+func TestListViewShowsPreview(t *testing.T) {
+	m := testList(listConfig{preview: func(i int) string { return "details of " + m0(i) }})
+	if !strings.Contains(m.View(), "loading...") {
+		t.Fatalf("expected a placeholder until the preview is rendered, got:\n%s", m.View())
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if cmd == nil {
+		t.Fatalf("expected moving the cursor to render its preview")
+	}
+	m.Update(cmd())
+	view := m.View()
+	if !strings.Contains(view, "details of kv-prod") {
+		t.Fatalf("expected preview of the highlighted item, got:\n%s", view)
+	}
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyDown}); cmd == nil {
+		t.Fatalf("expected the next item's preview to be rendered")
+	}
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyUp}); cmd != nil {
+		t.Fatalf("expected a rendered preview to be reused")
+	}
+
+	m.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+	if strings.Contains(m.View(), "details of") {
+		t.Fatalf("expected no preview on a narrow terminal")
+	}
+}
+
+func m0(i int) string {
+	return []string{"kubevault", "kv-prod", "web-prod"}[i]
+}
+
+func TestRunErrorMarksTerminalFailures(t *testing.T) {
+	err := runError(fmt.Errorf("could not open a new TTY: %w", os.ErrNotExist))
+	if !errors.Is(err, picker.ErrPickerUnavailable) {
+		t.Fatalf("expected a TTY failure to make the picker unavailable, got %v", err)
+	}
+	if err := runError(tea.ErrInterrupted); errors.Is(err, picker.ErrPickerUnavailable) || !errors.Is(err, tea.ErrInterrupted) {
+		t.Fatalf("expected an interrupt to be passed on, got %v", err)
+	}
+}
+
+func TestRunListWithoutTerminal(t *testing.T) {
+	if f, err := os.Open("/dev/tty"); err == nil {
+		_ = f.Close()
+		t.Skip("a terminal is available")
+	}
+	if _, err := runList(nil, listConfig{}); !errors.Is(err, picker.ErrPickerUnavailable) {
+		t.Fatalf("expected ErrPickerUnavailable without a terminal, got %v", err)
+	}
+}
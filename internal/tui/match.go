@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Score adjustments for fuzzy matches.
+const (
+	matchBonus       = 1 // every matched rune
+	consecutiveBonus = 4 // rune directly follows the previous match
+	wordStartBonus   = 6 // rune starts a word, e.g. after "-" or "/"
+	primaryBonus     = 2 // term matched the first field, usually the name
+)
+
+// match scores how well query matches the fields of an item. Every
+// space-separated term must match at least one field; the score is the sum
+// of each term's best field score. An empty query matches everything.
+func match(query string, fields []string) (int, bool) {
+	total := 0
+	for _, term := range strings.Fields(query) {
+		best, found := 0, false
+		for i, f := range fields {
+			s, ok := fuzzy(term, f)
+			if !ok {
+				continue
+			}
+			if i == 0 {
+				s += primaryBonus
+			}
+			if !found || s > best {
+				best, found = s, true
+			}
+		}
+		if !found {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// fuzzy reports whether the runes of pattern appear in order in text,
+// ignoring case, and scores the best such alignment. Consecutive runes and
+// runes at the start of a word score higher and gaps cost a point per
+// skipped rune, so "kv" ranks "kv-prod" above "kubevault".
+func fuzzy(pattern, text string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	orig := []rune(text)
+	t := []rune(strings.ToLower(text))
+	if len(p) == 0 {
+		return 0, true
+	}
+	if len(t) != len(orig) {
+		// Lowercasing changed the length; fall back to the lowered runes
+		// for word boundaries too.
+		orig = t
+	}
+
+	best, found := 0, false
+	for start := range t {
+		if t[start] != p[0] {
+			continue
+		}
+		score, ok := alignFrom(p, t, orig, start)
+		if ok && (!found || score > best) {
+			best, found = score, true
+		}
+	}
+	return best, found
+}
+
+// alignFrom greedily matches p against t starting at t[start].
+func alignFrom(p, t, orig []rune, start int) (int, bool) {
+	score, prev := 0, -1
+	pi := 0
+	for ti := start; ti < len(t) && pi < len(p); ti++ {
+		if t[ti] != p[pi] {
+			continue
+		}
+		score += matchBonus
+		if prev >= 0 {
+			if ti == prev+1 {
+				score += consecutiveBonus
+			} else {
+				score -= ti - prev - 1
+			}
+		}
+		if wordStart(orig, ti) {
+			score += wordStartBonus
+		}
+		prev = ti
+		pi++
+	}
+	return score, pi == len(p)
+}
+
+// wordStart reports whether s[i] begins a word: the first rune, a rune after
+// a separator, or an upper-case rune after a lower-case one.
+func wordStart(s []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	switch s[i-1] {
+	case ' ', '-', '_', '.', '/', ':':
+		return true
+	}
+	return unicode.IsUpper(s[i]) && unicode.IsLower(s[i-1])
+}
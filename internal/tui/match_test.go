@@ -0,0 +1,66 @@
+package tui
+
+import "testing"
+
+func TestFuzzy(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		ok            bool
+	}{
+		{"kv", "kv-prod", true},
+		{"KV", "kv-prod", true},
+		{"kvp", "kv-prod", true},
+		{"pk", "kv-prod", false},
+		{"", "anything", true},
+		{"xyz", "kv-prod", false},
+		{"app", "", false},
+	}
+	for _, tt := range tests {
+		if _, ok := fuzzy(tt.pattern, tt.text); ok != tt.ok {
+			t.Fatalf("fuzzy(%q, %q): expected ok=%v, got %v", tt.pattern, tt.text, tt.ok, ok)
+		}
+	}
+}
+
+func TestFuzzyRanksWordStartsAndRuns(t *testing.T) {
+	better, _ := fuzzy("kv", "kv-prod")
+	worse, _ := fuzzy("kv", "kubevault")
+	if better <= worse {
+		t.Fatalf("expected kv-prod (%d) to outrank kubevault (%d)", better, worse)
+	}
+
+	// The best alignment wins, not the leftmost one.
+	late, _ := fuzzy("web", "wxyz-web")
+	early, _ := fuzzy("web", "wxexb")
+	if late <= early {
+		t.Fatalf("expected wxyz-web (%d) to outrank wxexb (%d)", late, early)
+	}
+
+	camel, _ := fuzzy("ca", "myContainerApp")
+	flat, _ := fuzzy("ca", "mycontainerapp")
+	if camel <= flat {
+		t.Fatalf("expected camel-case word start (%d) to outrank flat text (%d)", camel, flat)
+	}
+}
+
+func TestMatchRequiresEveryTerm(t *testing.T) {
+	fields := []string{"web-prod", "microsoft.web/sites", "rg-shop", "westeurope"}
+
+	if _, ok := match("web shop", fields); !ok {
+		t.Fatalf("expected terms spread over fields to match")
+	}
+	if _, ok := match("web nope", fields); ok {
+		t.Fatalf("expected a missing term to fail the match")
+	}
+	if score, ok := match("", fields); !ok || score != 0 {
+		t.Fatalf("expected empty query to match with score 0, got %d, %v", score, ok)
+	}
+}
+
+func TestMatchPrefersFirstField(t *testing.T) {
+	name, _ := match("shop", []string{"shop", "other"})
+	group, _ := match("shop", []string{"other", "shop"})
+	if name <= group {
+		t.Fatalf("expected name match (%d) to outrank group match (%d)", name, group)
+	}
+}
@@ -0,0 +1,140 @@
+// Package tui implements the builtin terminal picker, used when fzf is not
+// installed or the config sets `ui: builtin`.
+package tui
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/chege/azfind/internal/actions"
+	"github.com/chege/azfind/internal/cache"
+	"github.com/chege/azfind/internal/picker"
+)
+
+// Picker is the builtin fuzzy picker.
+type Picker struct{}
+
+// Select implements picker.Picker. Tab marks several resources, enter picks
+// the default action, key bindings of the registry pick their own action and
+// ctrl-a shows an action menu.
+func (Picker) Select(resources []cache.Resource, opts picker.Options) (*picker.Selection, error) {
+	if len(resources) == 0 {
+		return nil, picker.ErrNoMatch
+	}
+
+	reg := opts.Actions
+	defaultAction := opts.DefaultAction
+	if defaultAction == "" {
+		defaultAction = actions.OpenPortal
+	}
+
+	width := picker.TerminalWidth()
+	if width >= minPreviewWidth {
+		width = picker.ListWidth(width, previewPercent)
+	} else {
+		width -= 3
+	}
+	labels := opts.Layout.Lines(resources, width, true)
+	styled := opts.Layout.Lines(resources, width, false)
+
+	items := make([]item, len(resources))
+	for i, r := range resources {
+		items[i] = item{
+			label:  labels[i],
+			styled: styled[i],
+			fields: []string{r.Name, r.Type, r.ResourceGroup, r.SubscriptionID, r.Location, r.ID, labels[i]},
+		}
+	}
+
+	res, err := runList(items, listConfig{
+		prompt:  "> ",
+		query:   opts.Query,
+		header:  picker.Header(opts.Header, defaultAction, reg),
+		multi:   true,
+		keys:    picker.Keys(reg),
+		preview: func(i int) string { return preview(opts.Preview, resources[i]) },
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, picker.ErrCancelled
+	}
+
+	picked := make([]cache.Resource, len(res.picked))
+	for i, idx := range res.picked {
+		picked[i] = resources[idx]
+	}
+
+	action, err := picker.ChooseAction(reg, res.key, defaultAction, picked, selectAction)
+	if err != nil {
+		return nil, err
+	}
+	return &picker.Selection{Resources: picked, Action: action}, nil
+}
+
+// preview runs cmd for r and returns its output, falling back to the cached
+// fields if there is no command or it fails.
+func preview(cmd []string, r cache.Resource) string {
+	if len(cmd) == 0 {
+		return details(r)
+	}
+	args := append(cmd[1:len(cmd):len(cmd)], r.ID)
+	out, err := exec.Command(cmd[0], args...).Output()
+	if err != nil {
+		return details(r)
+	}
+	return strings.TrimRight(string(out), "\n")
+}
+
+// details summarises the cached fields of a resource.
+func details(r cache.Resource) string {
+	return fmt.Sprintf("Type:            %s\nName:            %s\nSubscription:    %s\nTenant:          %s\nResource group:  %s\nLocation:        %s\nID:              %s\nSynced:          %s",
+		r.Type, r.Name, r.SubscriptionLabel(), r.TenantLabel(), r.ResourceGroup, r.Location, r.ID, picker.Freshness(r.UpdatedAt))
+}
+
+// List implements picker.Picker.
+func (Picker) List(lines []string, opts picker.ListOptions) (*picker.Choice, error) {
+	if len(lines) == 0 {
+		return nil, picker.ErrNoMatch
+	}
+
+	items := make([]item, len(lines))
+	for i, line := range lines {
+		items[i] = item{label: line, fields: []string{line}}
+	}
+
+	res, err := runList(items, listConfig{prompt: opts.Prompt, header: opts.Header, keys: opts.Keys})
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, picker.ErrCancelled
+	}
+	return &picker.Choice{Index: res.picked[0], Key: res.key}, nil
+}
+
+// selectAction shows the given actions in a list and returns the chosen name.
+func selectAction(list []actions.Action, prompt string) (string, error) {
+	items := make([]item, len(list))
+	for i, a := range list {
+		desc := a.Description
+		if a.Key != "" {
+			desc += " (" + a.Key + ")"
+		}
+		items[i] = item{
+			label:  fmt.Sprintf("%-20s %s", a.Name, desc),
+			fields: []string{a.Name, desc},
+		}
+	}
+
+	res, err := runList(items, listConfig{prompt: prompt + " > "})
+	if err != nil {
+		return "", err
+	}
+	if res == nil {
+		return "", picker.ErrCancelled
+	}
+	return list[res.picked[0]].Name, nil
+}